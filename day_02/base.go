@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	minBase = 2
+	maxBase = 36
+)
+
+// base prefixes that may be put in front of a bound, e.g. "0x1f-0x2a"
+var basePrefixes = map[string]int{
+	"0b": 2,
+	"0o": 8,
+	"0x": 16,
+}
+
+// FormatID renders an ID in the given base using lower case digits 0-9a-z.
+func FormatID(id uint, base int) string {
+	return strconv.FormatUint(uint64(id), base)
+}
+
+// parseBound parses one range bound. In base 10 (the default) a 0b/0o/0x prefix
+// switches the base for that bound; in other bases the digits are taken literally,
+// since e.g. "0b" is a valid number in base 16.
+// It returns the value, the base that was actually used and, when the bound was written
+// with leading zeros, the number of digits written; otherwise the width is 0.
+func parseBound(s string, base int) (uint, int, int, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if base == 10 && len(s) > 2 {
+		if prefixBase, ok := basePrefixes[s[:2]]; ok {
+			base = prefixBase
+			s = s[2:]
+		}
+	}
+	value, err := strconv.ParseUint(s, base, strconv.IntSize)
+	if err != nil {
+		return 0, base, 0, fmt.Errorf("invalid base-%d product ID %q: %w", base, s, err)
+	}
+	width := 0
+	if len(s) > len(FormatID(uint(value), base)) {
+		width = len(s)
+	}
+	return uint(value), base, width, nil
+}
+
+// ParseInputBase parses comma separated "lower-upper" ranges with bounds written in the given base.
+func ParseInputBase(input string, base int) ([]ProductIDRange, error) {
+	if base < minBase || base > maxBase {
+		return nil, fmt.Errorf("base %d out of range %d-%d", base, minBase, maxBase)
+	}
+
+	ranges := make([]ProductIDRange, 0)
+	input = strings.ReplaceAll(input, "\n", "")
+
+	for _, part := range strings.Split(input, ",") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		bounds := strings.Split(part, "-")
+		if len(bounds) != 2 {
+			return nil, fmt.Errorf("invalid range %q", part)
+		}
+		lower, lowerBase, width, err := parseBound(bounds[0], base)
+		if err != nil {
+			return nil, err
+		}
+		upper, upperBase, _, err := parseBound(bounds[1], base)
+		if err != nil {
+			return nil, err
+		}
+		if lowerBase != upperBase {
+			return nil, fmt.Errorf("range %q mixes base %d and base %d", part, lowerBase, upperBase)
+		}
+		ranges = append(ranges, ProductIDRange{lowerBound: lower, upperBound: upper, base: lowerBase, width: width})
+	}
+	return ranges, nil
+}
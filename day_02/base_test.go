@@ -0,0 +1,129 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseInputBase(t *testing.T) {
+	ranges, err := ParseInputBase("10-1f,0x20-0x2f", 16)
+	if err == nil {
+		t.Fatalf("expected an error for a 0x prefix in base 16, got %v", ranges)
+	}
+
+	ranges, err = ParseInputBase("10-1f,20-2f\n", 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ranges) != 2 || ranges[0].lowerBound != 16 || ranges[0].upperBound != 31 || ranges[1].Base() != 16 {
+		t.Errorf("unexpected ranges %+v", ranges)
+	}
+
+	ranges, err = ParseInputBase("11-22,0x10-0x1f,0b11-0b111", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bases := []int{10, 16, 2}
+	for i, r := range ranges {
+		if r.Base() != bases[i] {
+			t.Errorf("range %d: expected base %d, got %d", i, bases[i], r.Base())
+		}
+	}
+
+	for _, input := range []string{"0x10-20", "1-2-3", "zz-10"} {
+		if _, err := ParseInputBase(input, 10); err == nil {
+			t.Errorf("expected an error for %q", input)
+		}
+	}
+	if _, err := ParseInputBase("1-2", 37); err == nil {
+		t.Errorf("expected an error for base 37")
+	}
+}
+
+func TestInvalidIDsInBase(t *testing.T) {
+	// 0x10-0x30 contains 0x11, 0x22 in hex, which are 17 and 34 in decimal
+	r := ProductIDRange{lowerBound: 0x10, upperBound: 0x30, base: 16}
+	ids := r.InvalidIDs(RepeatedExactly{K: 2})
+	if len(ids) != 2 || ids[0] != 0x11 || ids[1] != 0x22 {
+		t.Errorf("expected [17 34], got %v", ids)
+	}
+
+	// base 36: "aa" = 370, "bb" = 407
+	r = ProductIDRange{lowerBound: 370, upperBound: 407, base: 36}
+	report := r.Report(RepeatedAtLeast{K: 2})
+	if report.Count != 2 || report.Sum != 777 || r.Format(report.Sum) != "ll" {
+		t.Errorf("unexpected report %s", report)
+	}
+}
+
+func TestLeadingZeroFromParsedRange(t *testing.T) {
+	ranges, err := ParseInputBase("0098-0101,98-101", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the first range keeps its four digits: "0098", "0099", "0100", "0101"
+	if got := ranges[0].Format(99); got != "0099" {
+		t.Errorf("Format(99) = %q; want %q", got, "0099")
+	}
+	if got := ranges[0].InvalidIDs(LeadingZero{}); len(got) != 4 {
+		t.Errorf("LeadingZero flagged %v in %s; want all 4 IDs", got, "0098-0101")
+	}
+	if got := ranges[1].InvalidIDs(LeadingZero{}); len(got) != 0 {
+		t.Errorf("LeadingZero flagged %v in %s; want none", got, "98-101")
+	}
+
+	// the padded range and the plain one are read differently, so they are kept apart,
+	// and the IDs they share are counted in both
+	if merged := NormalizeRanges(ranges, false); len(merged) != 2 {
+		t.Errorf("NormalizeRanges() = %+v; want both ranges kept", merged)
+	}
+}
+
+func TestMergeRangesOfDifferentLengths(t *testing.T) {
+	ranges, err := ParseInputBase("5-20,10-30", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	merged := NormalizeRanges(ranges, false)
+	if len(merged) != 1 || merged[0].lowerBound != 5 || merged[0].upperBound != 30 {
+		t.Errorf("NormalizeRanges() = %+v; want a single range 5-30", merged)
+	}
+
+	var out strings.Builder
+	if err := SumInvalidIDs(strings.NewReader("5-20,10-30"), &out, RepeatedExactly{K: 2}, false, 10); err != nil {
+		t.Fatal(err)
+	}
+	// 11 + 22, with 11 counted once
+	if !strings.HasSuffix(out.String(), "Total sum of invalid product IDs: 33\n") {
+		t.Errorf("unexpected output:\n%s", out.String())
+	}
+}
+
+func TestSumInvalidIDs(t *testing.T) {
+	var out strings.Builder
+	if err := SumInvalidIDs(strings.NewReader("11-22,0x10-0x1f\n"), &out, RepeatedExactly{K: 2}, false, 10); err != nil {
+		t.Fatal(err)
+	}
+	// 11 and 22 in decimal, 0x11 = 17 in hex: the total is always decimal
+	if !strings.HasSuffix(out.String(), "Total sum of invalid product IDs: 50\n") {
+		t.Errorf("unexpected output:\n%s", out.String())
+	}
+
+	out.Reset()
+	if err := SumInvalidIDs(strings.NewReader("10-1f"), &out, RepeatedExactly{K: 2}, false, 16); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(out.String(), "Total sum of invalid product IDs: 17 (base 16: 11)\n") {
+		t.Errorf("unexpected output:\n%s", out.String())
+	}
+
+	for _, input := range []string{"11-22,zz-30", "1-2-3"} {
+		if err := SumInvalidIDs(strings.NewReader(input), &out, RepeatedExactly{K: 2}, false, 10); err == nil {
+			t.Errorf("expected an error for %q", input)
+		}
+	}
+	if err := SumInvalidIDs(strings.NewReader("1-2"), &out, RepeatedExactly{K: 2}, false, 40); err == nil {
+		t.Errorf("expected an error for base 40")
+	}
+}
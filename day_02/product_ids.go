@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"io"
 	"os"
@@ -32,18 +33,32 @@ func HasLeadingZero(productID string) bool {
 }
 
 func InvalidProductIDs(lowerBound uint, upperBound uint) []uint {
-	invalidProductIDs := make([]uint, 0)
-	for i := lowerBound; i <= upperBound; i++ {
-		if IsRepeatingSequenceInteger(i) {
-			invalidProductIDs = append(invalidProductIDs, i)
-		}
-	}
-	return invalidProductIDs
+	return ProductIDRange{lowerBound: lowerBound, upperBound: upperBound}.InvalidIDs(RepeatedExactly{K: 2})
 }
 
 type ProductIDRange struct {
 	lowerBound uint
 	upperBound uint
+	base       int // base the IDs are written in; 0 means decimal
+	width      int // digits of a lower bound written with leading zeros, 0 if it had none; shorter IDs are zero-padded to it
+}
+
+// Base returns the base the range's IDs are written in.
+func (r ProductIDRange) Base() int {
+	if r.base == 0 {
+		return 10
+	}
+	return r.base
+}
+
+// Format renders an ID of this range in the range's base, keeping the leading zeros
+// the lower bound was written with, so that "007-012" gives "007", "008", ... "012".
+func (r ProductIDRange) Format(id uint) string {
+	s := FormatID(id, r.Base())
+	if len(s) < r.width {
+		s = strings.Repeat("0", r.width-len(s)) + s
+	}
+	return s
 }
 
 func ParseInput(input string) []ProductIDRange {
@@ -58,73 +73,98 @@ func ParseInput(input string) []ProductIDRange {
 		if len(bounds) != 2 {
 			continue
 		}
-		var lower, upper uint
-		fmt.Sscanf(bounds[0], "%d", &lower)
-		fmt.Sscanf(bounds[1], "%d", &upper)
-		ranges = append(ranges, ProductIDRange{lowerBound: lower, upperBound: upper})
+		// malformed ranges are skipped, use ParseInputBase to get the error instead
+		lower, lowerBase, width, err := parseBound(bounds[0], 10)
+		if err != nil {
+			continue
+		}
+		upper, upperBase, _, err := parseBound(bounds[1], 10)
+		if err != nil || lowerBase != upperBase {
+			continue
+		}
+		ranges = append(ranges, ProductIDRange{lowerBound: lower, upperBound: upper, base: lowerBase, width: width})
 	}
 	return ranges
 }
 
-func Part1() {
-	data, err := io.ReadAll(os.Stdin)
-	if err != nil {
-		panic(err)
-	}
-
-	productIDRanges := ParseInput(string(data))
-
-	totalSum := 0
-	for _, pidRange := range productIDRanges {
-		invalidIDs := InvalidProductIDs(pidRange.lowerBound, pidRange.upperBound)
-		fmt.Printf("Invalid Product IDs in range %d-%d: %v\n", pidRange.lowerBound, pidRange.upperBound, invalidIDs)
-		for _, id := range invalidIDs {
-			totalSum += int(id)
+// InvalidIDs returns all IDs in the range that the rule marks as invalid.
+func (r ProductIDRange) InvalidIDs(rule Rule) []uint {
+	invalidIDs := make([]uint, 0)
+	r.each(func(i uint) {
+		if rule.IsInvalid(r.Format(i)) {
+			invalidIDs = append(invalidIDs, i)
 		}
-	}
-	fmt.Printf("Total sum of invalid product IDs: %d\n", totalSum)
+	})
+	return invalidIDs
 }
 
-func isRepeated(s string) bool {
-	n := len(s)
-	for size := 1; size <= n/2; size++ {
-		if n%size != 0 {
-			continue
-		}
-		prefix := s[:size]
-		if strings.Repeat(prefix, n/size) == s {
-			return true
+// each calls f with every ID of the range in increasing order. The loop stops on the upper
+// bound itself rather than on i > upperBound, which would never happen at the top of uint.
+func (r ProductIDRange) each(f func(id uint)) {
+	if r.lowerBound > r.upperBound {
+		return
+	}
+	for i := r.lowerBound; ; i++ {
+		f(i)
+		if i == r.upperBound {
+			return
 		}
 	}
-	return false
 }
 
-func Part2() {
-	data, err := io.ReadAll(os.Stdin)
+// SumInvalidIDs applies the rule to every range read from r, writes a report per range
+// and the total of the invalid IDs to w. Overlapping ranges are merged unless keepDuplicates is set.
+// Bounds are read in the given base (or the base named by their 0b/0o/0x prefix) and each
+// range is reported in its own base. Ranges can mix bases, so the total is written in decimal,
+// followed by its value in the given base when that isn't 10.
+func SumInvalidIDs(r io.Reader, w io.Writer, rule Rule, keepDuplicates bool, base int) error {
+	data, err := io.ReadAll(r)
 	if err != nil {
-		panic(err)
+		return err
 	}
 
-	productIDRanges := ParseInput(string(data))
+	productIDRanges, err := ParseInputBase(string(data), base)
+	if err != nil {
+		return err
+	}
+	productIDRanges = NormalizeRanges(productIDRanges, keepDuplicates)
 
-	totalSum := 0
+	var totalSum uint
 	for _, pidRange := range productIDRanges {
-		invalidIDs := make([]uint, 0)
-		for i := pidRange.lowerBound; i <= pidRange.upperBound; i++ {
-			idStr := fmt.Sprintf("%d", i)
-			if isRepeated(idStr) {
-				invalidIDs = append(invalidIDs, i)
-			}
-		}
-		fmt.Printf("Invalid Product IDs in range %d-%d: %v\n", pidRange.lowerBound, pidRange.upperBound, invalidIDs)
-		for _, id := range invalidIDs {
-			totalSum += int(id)
-		}
+		report := pidRange.Report(rule)
+		fmt.Fprintln(w, report)
+		totalSum += report.Sum
+	}
+	if base != 10 {
+		_, err = fmt.Fprintf(w, "Total sum of invalid product IDs: %d (base %d: %s)\n", totalSum, base, FormatID(totalSum, base))
+	} else {
+		_, err = fmt.Fprintf(w, "Total sum of invalid product IDs: %d\n", totalSum)
 	}
-	fmt.Printf("Total sum of invalid product IDs: %d\n", totalSum)
+	return err
 }
 
 func main() {
-	//Part1()
-	Part2()
+	part := flag.Int("part", 2, "which part to solve (1 or 2)")
+	ruleExpr := flag.String("rule", "", "custom invalid-ID rule, e.g. 'or(repeat-exact:2,palindrome)'; overrides -part")
+	keepDuplicates := flag.Bool("keep-duplicates", false, "do not merge overlapping ranges")
+	base := flag.Int("base", 10, "base (2-36) the product IDs are written in")
+	flag.Parse()
+
+	var rule Rule = RepeatedAtLeast{K: 2}
+	if *part == 1 {
+		rule = RepeatedExactly{K: 2}
+	}
+	if *ruleExpr != "" {
+		var err error
+		rule, err = ParseRule(*ruleExpr)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+
+	if err := SumInvalidIDs(os.Stdin, os.Stdout, rule, *keepDuplicates, *base); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+// NormalizeRanges swaps reversed bounds and, unless keepDuplicates is set,
+// merges overlapping ranges so that no ID is counted twice.
+// Ranges are only merged when they share a base and a zero padding, since e.g. "0098-0101"
+// and "98-101" spell the same IDs differently; overlapping ranges that differ in either
+// are kept apart and the IDs they share are counted once per range.
+// The result is sorted by lower bound.
+func NormalizeRanges(ranges []ProductIDRange, keepDuplicates bool) []ProductIDRange {
+	normalized := make([]ProductIDRange, 0, len(ranges))
+	for _, r := range ranges {
+		if r.lowerBound > r.upperBound {
+			r.lowerBound, r.upperBound = r.upperBound, r.lowerBound
+		}
+		normalized = append(normalized, r)
+	}
+
+	sort.Slice(normalized, func(i, j int) bool {
+		if normalized[i].lowerBound != normalized[j].lowerBound {
+			return normalized[i].lowerBound < normalized[j].lowerBound
+		}
+		return normalized[i].upperBound < normalized[j].upperBound
+	})
+
+	if keepDuplicates {
+		return normalized
+	}
+
+	merged := make([]ProductIDRange, 0, len(normalized))
+	for _, r := range normalized {
+		// ranges written in different bases or paddings are checked differently, so they never merge
+		last := len(merged) - 1
+		if last >= 0 && r.Base() == merged[last].Base() && r.width == merged[last].width && r.lowerBound <= merged[last].upperBound {
+			merged[last].upperBound = max(merged[last].upperBound, r.upperBound)
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+// RangeReport summarizes the invalid IDs found in one range.
+// Min and Max are only meaningful when Count > 0.
+type RangeReport struct {
+	Range ProductIDRange
+	Count int
+	Sum   uint
+	Min   uint
+	Max   uint
+}
+
+// Report scans the range with the given rule without keeping the individual IDs.
+func (r ProductIDRange) Report(rule Rule) RangeReport {
+	report := RangeReport{Range: r}
+	r.each(func(i uint) {
+		if !rule.IsInvalid(r.Format(i)) {
+			return
+		}
+		if report.Count == 0 {
+			report.Min = i
+		}
+		report.Max = i
+		report.Count++
+		report.Sum += i
+	})
+	return report
+}
+
+// String prints the report with all IDs written in the range's base.
+func (rr RangeReport) String() string {
+	r := rr.Range
+	header := fmt.Sprintf("range %s-%s", r.Format(r.lowerBound), r.Format(r.upperBound))
+	if r.Base() != 10 {
+		header += fmt.Sprintf(" (base %d)", r.Base())
+	}
+	if rr.Count == 0 {
+		return header + ": count=0 sum=0 min=- max=-"
+	}
+	return fmt.Sprintf("%s: count=%d sum=%s min=%s max=%s",
+		header, rr.Count, r.Format(rr.Sum), r.Format(rr.Min), r.Format(rr.Max))
+}
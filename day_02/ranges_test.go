@@ -0,0 +1,68 @@
+package main
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeRanges(t *testing.T) {
+	input := []ProductIDRange{
+		{lowerBound: 20, upperBound: 10},
+		{lowerBound: 15, upperBound: 30},
+		{lowerBound: 40, upperBound: 50},
+		{lowerBound: 1, upperBound: 5},
+	}
+
+	merged := NormalizeRanges(input, false)
+	expected := []ProductIDRange{
+		{lowerBound: 1, upperBound: 5},
+		{lowerBound: 10, upperBound: 30},
+		{lowerBound: 40, upperBound: 50},
+	}
+	if !reflect.DeepEqual(merged, expected) {
+		t.Errorf("expected %v, got %v", expected, merged)
+	}
+
+	kept := NormalizeRanges(input, true)
+	expected = []ProductIDRange{
+		{lowerBound: 1, upperBound: 5},
+		{lowerBound: 10, upperBound: 20},
+		{lowerBound: 15, upperBound: 30},
+		{lowerBound: 40, upperBound: 50},
+	}
+	if !reflect.DeepEqual(kept, expected) {
+		t.Errorf("expected %v, got %v", expected, kept)
+	}
+}
+
+func TestRangeReport(t *testing.T) {
+	report := ProductIDRange{lowerBound: 95, upperBound: 115}.Report(RepeatedAtLeast{K: 2})
+	expected := RangeReport{Range: ProductIDRange{lowerBound: 95, upperBound: 115}, Count: 2, Sum: 210, Min: 99, Max: 111}
+	if report != expected {
+		t.Errorf("expected %+v, got %+v", expected, report)
+	}
+
+	empty := ProductIDRange{lowerBound: 12, upperBound: 20}.Report(RepeatedExactly{K: 2})
+	if empty.Count != 0 || empty.Sum != 0 {
+		t.Errorf("expected empty report, got %+v", empty)
+	}
+}
+
+func TestRangeAtTopOfUint(t *testing.T) {
+	// math.MaxUint itself is valid, so the loop must stop on the bound, not on an invalid ID
+	r := ProductIDRange{lowerBound: math.MaxUint - 3, upperBound: math.MaxUint}
+	if (RepeatedExactly{K: 2}).IsInvalid(r.Format(math.MaxUint)) {
+		t.Fatalf("%s should be a valid ID", r.Format(math.MaxUint))
+	}
+
+	if report := r.Report(RepeatedExactly{K: 2}); report.Count != 0 {
+		t.Errorf("expected no invalid ID, got %+v", report)
+	}
+	if ids := r.InvalidIDs(RepeatedExactly{K: 2}); len(ids) != 0 {
+		t.Errorf("expected no invalid ID, got %v", ids)
+	}
+	if ids := r.InvalidIDs(Not{Rule: RepeatedExactly{K: 2}}); len(ids) != 4 || ids[3] != math.MaxUint {
+		t.Errorf("expected the 4 IDs up to math.MaxUint, got %v", ids)
+	}
+}
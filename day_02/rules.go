@@ -0,0 +1,266 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// A Rule decides whether a product ID (in its textual form) is invalid.
+type Rule interface {
+	IsInvalid(productID string) bool
+	String() string
+}
+
+// RepeatedExactly matches IDs made of some block repeated exactly K times, e.g. K=2 matches "1212".
+type RepeatedExactly struct {
+	K int
+}
+
+func (r RepeatedExactly) IsInvalid(productID string) bool {
+	n := len(productID)
+	if r.K < 1 || n == 0 || n%r.K != 0 {
+		return false
+	}
+	return strings.Repeat(productID[:n/r.K], r.K) == productID
+}
+
+func (r RepeatedExactly) String() string { return fmt.Sprintf("repeat-exact:%d", r.K) }
+
+// RepeatedAtLeast matches IDs made of some block repeated K or more times.
+type RepeatedAtLeast struct {
+	K int
+}
+
+func (r RepeatedAtLeast) IsInvalid(productID string) bool {
+	n := len(productID)
+	for times := max(r.K, 1); times <= n; times++ {
+		if (RepeatedExactly{K: times}).IsInvalid(productID) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r RepeatedAtLeast) String() string { return fmt.Sprintf("repeat-atleast:%d", r.K) }
+
+// Palindrome matches IDs that read the same in both directions.
+type Palindrome struct{}
+
+func (Palindrome) IsInvalid(productID string) bool {
+	for i, j := 0, len(productID)-1; i < j; i, j = i+1, j-1 {
+		if productID[i] != productID[j] {
+			return false
+		}
+	}
+	return len(productID) > 0
+}
+
+func (Palindrome) String() string { return "palindrome" }
+
+// LeadingZero matches IDs starting with '0'.
+type LeadingZero struct{}
+
+func (LeadingZero) IsInvalid(productID string) bool {
+	return len(productID) > 0 && HasLeadingZero(productID)
+}
+
+func (LeadingZero) String() string { return "leading-zero" }
+
+// DigitSum compares the sum of the ID's digits against Value.
+// Op is one of "lt", "le", "eq", "ne", "ge", "gt", or "mod" (digit sum divisible by Value).
+type DigitSum struct {
+	Op    string
+	Value int
+}
+
+func (r DigitSum) IsInvalid(productID string) bool {
+	sum := digitSum(productID)
+	switch r.Op {
+	case "lt":
+		return sum < r.Value
+	case "le":
+		return sum <= r.Value
+	case "eq":
+		return sum == r.Value
+	case "ne":
+		return sum != r.Value
+	case "ge":
+		return sum >= r.Value
+	case "gt":
+		return sum > r.Value
+	case "mod":
+		return r.Value != 0 && sum%r.Value == 0
+	}
+	return false
+}
+
+func (r DigitSum) String() string { return fmt.Sprintf("digitsum-%s:%d", r.Op, r.Value) }
+
+// digitSum adds up the digit values of s; letters count as digits 10..35 so that
+// non-decimal IDs are handled the same way.
+func digitSum(s string) int {
+	sum := 0
+	for _, c := range strings.ToLower(s) {
+		switch {
+		case c >= '0' && c <= '9':
+			sum += int(c - '0')
+		case c >= 'a' && c <= 'z':
+			sum += int(c-'a') + 10
+		}
+	}
+	return sum
+}
+
+// And matches when every one of its rules matches.
+type And []Rule
+
+func (a And) IsInvalid(productID string) bool {
+	for _, r := range a {
+		if !r.IsInvalid(productID) {
+			return false
+		}
+	}
+	return true
+}
+
+func (a And) String() string { return "and(" + joinRules(a) + ")" }
+
+// Or matches when any of its rules matches.
+type Or []Rule
+
+func (o Or) IsInvalid(productID string) bool {
+	for _, r := range o {
+		if r.IsInvalid(productID) {
+			return true
+		}
+	}
+	return false
+}
+
+func (o Or) String() string { return "or(" + joinRules(o) + ")" }
+
+// Not inverts a rule.
+type Not struct {
+	Rule Rule
+}
+
+func (n Not) IsInvalid(productID string) bool { return !n.Rule.IsInvalid(productID) }
+
+func (n Not) String() string { return "not(" + n.Rule.String() + ")" }
+
+func joinRules(rules []Rule) string {
+	parts := make([]string, len(rules))
+	for i, r := range rules {
+		parts[i] = r.String()
+	}
+	return strings.Join(parts, ",")
+}
+
+// ParseRule builds a Rule from an expression such as
+//
+//	and(repeat-atleast:2,not(palindrome))
+//
+// Leaf rules are repeat-exact:K, repeat-atleast:K, palindrome, leading-zero and
+// digitsum-OP:N; they can be combined with and(...), or(...) and not(...).
+func ParseRule(expr string) (Rule, error) {
+	p := &ruleParser{input: strings.ReplaceAll(expr, " ", "")}
+	rule, err := p.parse()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos != len(p.input) {
+		return nil, fmt.Errorf("unexpected %q at position %d in rule %q", p.input[p.pos:], p.pos, expr)
+	}
+	return rule, nil
+}
+
+type ruleParser struct {
+	input string
+	pos   int
+}
+
+func (p *ruleParser) parse() (Rule, error) {
+	start := p.pos
+	for p.pos < len(p.input) && !strings.ContainsRune("(),", rune(p.input[p.pos])) {
+		p.pos++
+	}
+	name := p.input[start:p.pos]
+	if name == "" {
+		return nil, fmt.Errorf("expected a rule at position %d in %q", start, p.input)
+	}
+
+	if p.pos < len(p.input) && p.input[p.pos] == '(' {
+		p.pos++
+		args, err := p.parseArgs()
+		if err != nil {
+			return nil, err
+		}
+		switch name {
+		case "and":
+			return And(args), nil
+		case "or":
+			return Or(args), nil
+		case "not":
+			if len(args) != 1 {
+				return nil, fmt.Errorf("not() takes exactly one rule, got %d", len(args))
+			}
+			return Not{Rule: args[0]}, nil
+		}
+		return nil, fmt.Errorf("unknown rule combinator %q", name)
+	}
+
+	return parseLeafRule(name)
+}
+
+func (p *ruleParser) parseArgs() ([]Rule, error) {
+	args := make([]Rule, 0)
+	for {
+		arg, err := p.parse()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+		if p.pos >= len(p.input) {
+			return nil, fmt.Errorf("missing ')' in %q", p.input)
+		}
+		c := p.input[p.pos]
+		p.pos++
+		if c == ')' {
+			return args, nil
+		}
+		if c != ',' {
+			return nil, fmt.Errorf("unexpected %q at position %d in %q", c, p.pos-1, p.input)
+		}
+	}
+}
+
+func parseLeafRule(name string) (Rule, error) {
+	kind, arg, hasArg := strings.Cut(name, ":")
+	value := 0
+	if hasArg {
+		var err error
+		value, err = strconv.Atoi(arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid argument in rule %q: %w", name, err)
+		}
+	}
+
+	switch {
+	case kind == "repeat-exact" && hasArg:
+		return RepeatedExactly{K: value}, nil
+	case kind == "repeat-atleast" && hasArg:
+		return RepeatedAtLeast{K: value}, nil
+	case kind == "palindrome" && !hasArg:
+		return Palindrome{}, nil
+	case kind == "leading-zero" && !hasArg:
+		return LeadingZero{}, nil
+	case strings.HasPrefix(kind, "digitsum-") && hasArg:
+		op := strings.TrimPrefix(kind, "digitsum-")
+		switch op {
+		case "lt", "le", "eq", "ne", "ge", "gt", "mod":
+			return DigitSum{Op: op, Value: value}, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown rule %q", name)
+}
@@ -0,0 +1,86 @@
+package main
+
+import "testing"
+
+func TestBuiltinRules(t *testing.T) {
+	tests := []struct {
+		rule      Rule
+		productID string
+		expected  bool
+	}{
+		{RepeatedExactly{K: 2}, "1212", true},
+		{RepeatedExactly{K: 2}, "111", false},
+		{RepeatedExactly{K: 3}, "111", true},
+		{RepeatedExactly{K: 3}, "121212", true},
+		{RepeatedAtLeast{K: 2}, "121212", true},
+		{RepeatedAtLeast{K: 2}, "1213", false},
+		{RepeatedAtLeast{K: 3}, "1212", false},
+		{RepeatedAtLeast{K: 3}, "1111", true},
+		{Palindrome{}, "12321", true},
+		{Palindrome{}, "1232", false},
+		{LeadingZero{}, "012", true},
+		{LeadingZero{}, "102", false},
+		{DigitSum{Op: "lt", Value: 5}, "1201", true},
+		{DigitSum{Op: "eq", Value: 6}, "123", true},
+		{DigitSum{Op: "mod", Value: 3}, "123", true},
+		{DigitSum{Op: "gt", Value: 6}, "123", false},
+	}
+
+	for _, test := range tests {
+		result := test.rule.IsInvalid(test.productID)
+		if result != test.expected {
+			t.Errorf("rule %s on %s: expected %v but got %v", test.rule, test.productID, test.expected, result)
+		}
+	}
+}
+
+func TestParseRule(t *testing.T) {
+	tests := []struct {
+		expr     string
+		valid    []string
+		invalid  []string
+		expected string
+	}{
+		{"repeat-exact:2", []string{"123"}, []string{"1212"}, "repeat-exact:2"},
+		{"and(repeat-atleast:2, not(palindrome))", []string{"1111", "123"}, []string{"1212"}, "and(repeat-atleast:2,not(palindrome))"},
+		{"or(leading-zero,digitsum-ge:20)", []string{"123"}, []string{"0123", "9993"}, "or(leading-zero,digitsum-ge:20)"},
+	}
+
+	for _, test := range tests {
+		rule, err := ParseRule(test.expr)
+		if err != nil {
+			t.Fatalf("ParseRule(%q) failed: %v", test.expr, err)
+		}
+		if rule.String() != test.expected {
+			t.Errorf("ParseRule(%q) = %s, expected %s", test.expr, rule, test.expected)
+		}
+		for _, id := range test.valid {
+			if rule.IsInvalid(id) {
+				t.Errorf("rule %s should accept %s", rule, id)
+			}
+		}
+		for _, id := range test.invalid {
+			if !rule.IsInvalid(id) {
+				t.Errorf("rule %s should reject %s", rule, id)
+			}
+		}
+	}
+
+	for _, expr := range []string{"", "bogus", "not(palindrome,leading-zero)", "and(palindrome", "repeat-exact:x", "palindrome)"} {
+		if _, err := ParseRule(expr); err == nil {
+			t.Errorf("ParseRule(%q) expected an error", expr)
+		}
+	}
+}
+
+func TestInvalidIDsPerPart(t *testing.T) {
+	r := ProductIDRange{lowerBound: 95, upperBound: 115}
+	part1 := r.InvalidIDs(RepeatedExactly{K: 2})
+	if len(part1) != 1 || part1[0] != 99 {
+		t.Errorf("expected [99] for part 1, got %v", part1)
+	}
+	part2 := r.InvalidIDs(RepeatedAtLeast{K: 2})
+	if len(part2) != 2 || part2[0] != 99 || part2[1] != 111 {
+		t.Errorf("expected [99 111] for part 2, got %v", part2)
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -36,9 +37,91 @@ func TestLargestNDigitNumber(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		result := LargestNDigitNumber(test.joltages, test.ndigits)
-		if result != test.expected {
+		result, err := LargestNDigitNumber(test.joltages, test.ndigits)
+		if err != nil {
+			t.Fatalf("For joltages %s and ndigits %d, unexpected error %v", test.joltages, test.ndigits, err)
+		}
+		if result.Int64() != int64(test.expected) {
 			t.Errorf("For joltages %s and ndigits %d, expected %d but got %d", test.joltages, test.ndigits, test.expected, result)
 		}
 	}
 }
+
+func TestLargestDigits(t *testing.T) {
+	tests := []struct {
+		joltages string
+		ndigits  int
+		expected string
+	}{
+		{"987654321111111", 0, ""},
+		{"12345", 5, "12345"},
+		{"9192939495", 3, "999"},
+		{"3333", 2, "33"},
+		{"818181911112111", 12, "888911112111"},
+		// more than 18 digits would overflow an int
+		{"98765432109876543210999", 21, "987654329876543210999"},
+	}
+
+	for _, test := range tests {
+		result, err := LargestDigits(test.joltages, test.ndigits)
+		if err != nil {
+			t.Fatalf("For joltages %s and ndigits %d, unexpected error %v", test.joltages, test.ndigits, err)
+		}
+		if result != test.expected {
+			t.Errorf("For joltages %s and ndigits %d, expected %s but got %s", test.joltages, test.ndigits, test.expected, result)
+		}
+	}
+
+	number, err := LargestNDigitNumber("98765432109876543210999", 21)
+	if err != nil || number.String() != "987654329876543210999" {
+		t.Errorf("expected 987654329876543210999, got %v (%v)", number, err)
+	}
+}
+
+func TestLargestDigitsErrors(t *testing.T) {
+	if _, err := LargestDigits("123", 4); err == nil {
+		t.Errorf("expected an error for a short bank")
+	}
+	if _, err := LargestDigits("12a4", 2); err == nil {
+		t.Errorf("expected an error for a non-digit bank")
+	}
+	if LargestTwoDigitNumber("7") != -1 {
+		t.Errorf("expected -1 for a one-digit bank")
+	}
+}
+
+func TestSelectLargest(t *testing.T) {
+	selection, err := SelectLargest("811111111111119", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(selection.Indices) != 2 || selection.Indices[0] != 0 || selection.Indices[1] != 14 {
+		t.Errorf("expected indices [0 14], got %v", selection.Indices)
+	}
+	if selection.Digits() != "89" || selection.Value().Int64() != 89 {
+		t.Errorf("expected 89, got %s", selection.Digits())
+	}
+	if rendered := selection.Render(); rendered != "[8]1111111111111[9]" {
+		t.Errorf("unexpected rendering %s", rendered)
+	}
+}
+
+func TestReportBanks(t *testing.T) {
+	var sb strings.Builder
+	sum, err := ReportBanks(&sb, []string{"987654321111111", "", "811111111111119"}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sum.Int64() != 98+89 {
+		t.Errorf("expected %d, got %s", 98+89, sum)
+	}
+	expected := "bank 0: indices [0 1] joltage 98\n  [9][8]7654321111111\n" +
+		"bank 2: indices [0 14] joltage 89\n  [8]1111111111111[9]\n"
+	if sb.String() != expected {
+		t.Errorf("expected report\n%s\ngot\n%s", expected, sb.String())
+	}
+
+	if _, err := ReportBanks(&sb, []string{"12", "1"}, 2); err == nil {
+		t.Errorf("expected an error for a short bank")
+	}
+}
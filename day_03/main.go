@@ -5,79 +5,63 @@ package main
 import (
 	"fmt"
 	"io"
+	"math/big"
 	"os"
 	"strings"
 )
 
 // generate the largest two-digit number by picking digits from the input slice
 // the ten's digt must come before the one's digit in the input slice
+// returns -1 if the bank is too short or not made of digits
 func LargestTwoDigitNumber(numberStr string) int {
-	numbers := make([]int, 0)
-	for _, ch := range numberStr {
-		numbers = append(numbers, int(ch-'0'))
+	digits, err := LargestDigits(numberStr, 2)
+	if err != nil {
+		return -1
 	}
+	return int(digits[0]-'0')*10 + int(digits[1]-'0')
+}
 
-	if len(numbers) < 2 {
-		return -1 // or some error value indicating not enough digits
+// LargestDigits picks ndigits digits from the bank, keeping their order, so that
+// they form the largest possible number, and returns them as a string.
+func LargestDigits(bank string, ndigits int) (string, error) {
+	selection, err := SelectLargest(bank, ndigits)
+	if err != nil {
+		return "", err
 	}
+	return selection.Digits(), nil
+}
 
-	// find the largest digits from numbers[:len(numbers)-2]
-	maxTens := -1
-	maxTensIndex := -1
-	for i := 0; i <= len(numbers)-2; i++ {
-		if numbers[i] > maxTens {
-			maxTens = numbers[i]
-			maxTensIndex = i
-		}
+// SelectLargest finds which ndigits batteries of the bank to switch on for the largest joltage.
+//
+// It keeps a monotonic stack of chosen positions: a smaller digit on top of the stack is
+// dropped whenever a larger one arrives and we can still afford to skip digits.
+// Every position is pushed and popped at most once, so this is O(len(bank)).
+func SelectLargest(bank string, ndigits int) (Selection, error) {
+	if err := validateBank(bank, ndigits); err != nil {
+		return Selection{}, err
 	}
 
-	// find the largest digit from numbers[maxTensIndex+1:]
-	maxOnes := -1
-	for i := maxTensIndex + 1; i < len(numbers); i++ {
-		if numbers[i] > maxOnes {
-			maxOnes = numbers[i]
+	stack := make([]int, 0, len(bank))
+	canDrop := len(bank) - ndigits
+	for i := 0; i < len(bank); i++ {
+		d := bank[i]
+		for canDrop > 0 && len(stack) > 0 && bank[stack[len(stack)-1]] < d {
+			stack = stack[:len(stack)-1]
+			canDrop--
 		}
+		stack = append(stack, i)
 	}
 
-	return maxTens*10 + maxOnes
-
+	return Selection{Bank: bank, Indices: stack[:ndigits]}, nil
 }
 
-func LargestNDigitNumber(numberStr string, ndigits int) int {
-	numbers := make([]int, 0)
-	for _, ch := range numberStr {
-		numbers = append(numbers, int(ch-'0'))
-	}
-
-	if len(numbers) < ndigits {
-		panic(fmt.Sprintf("Input string %s has less than %d digits", numberStr, ndigits))
-	}
-
-	maxDigits := make([]int, ndigits)
-
-	// find the largest digit and its index from numbers[:len(numbers)-n+1]
-	// after that, find the next largest from the remaining slice, and so on
-	startIndex := 0
-	for i := 1; i <= ndigits; i++ {
-		maxDigit := -1
-		maxIndex := -1
-		for j := startIndex; j < len(numbers)-ndigits+i; j++ {
-			if numbers[j] > maxDigit {
-				maxDigit = numbers[j]
-				maxIndex = j
-			}
-		}
-		maxDigits[i-1] = maxDigit
-		startIndex = maxIndex + 1
-	}
-
-	// construct the largest n-digit number
-	largestNumber := 0
-	for i := 0; i < ndigits; i++ {
-		largestNumber = largestNumber*10 + maxDigits[i]
+// LargestNDigitNumber is LargestDigits returned as a number, which does not overflow for large ndigits.
+func LargestNDigitNumber(numberStr string, ndigits int) (*big.Int, error) {
+	selection, err := SelectLargest(numberStr, ndigits)
+	if err != nil {
+		return nil, err
 	}
-
-	return largestNumber
+	return selection.Value(), nil
 }
 
 func ParseInput(r io.Reader) []string {
@@ -90,14 +74,31 @@ func ParseInput(r io.Reader) []string {
 	return lines
 }
 
-func Part1() {
+// ReportBanks prints, for every bank, which batteries were switched on and the resulting joltage,
+// and returns the sum of all joltages.
+func ReportBanks(w io.Writer, banks []string, ndigits int) (*big.Int, error) {
+	sumJoltages := new(big.Int)
+	for i, bank := range banks {
+		if bank == "" {
+			continue
+		}
+		selection, err := SelectLargest(bank, ndigits)
+		if err != nil {
+			return nil, fmt.Errorf("bank %d: %w", i, err)
+		}
+		joltage := selection.Value()
+		fmt.Fprintf(w, "bank %d: indices %v joltage %s\n  %s\n", i, selection.Indices, joltage, selection.Render())
+		sumJoltages.Add(sumJoltages, joltage)
+	}
+	return sumJoltages, nil
+}
 
+func Part1() {
 	inputs := ParseInput(os.Stdin)
 
-	sumJoltages := 0
-	for _, line := range inputs {
-		joltage := LargestTwoDigitNumber(line)
-		sumJoltages += joltage
+	sumJoltages, err := ReportBanks(os.Stdout, inputs, 2)
+	if err != nil {
+		panic(err)
 	}
 
 	fmt.Println("Sum of largest two-digit joltages:", sumJoltages)
@@ -106,15 +107,12 @@ func Part1() {
 func Part2() {
 	inputs := ParseInput(os.Stdin)
 
-	sumJoltages := 0
-	const ndigits = 12
-	for _, line := range inputs {
-
-		joltage := LargestNDigitNumber(line, ndigits)
-		sumJoltages += joltage
+	sumJoltages, err := ReportBanks(os.Stdout, inputs, 12)
+	if err != nil {
+		panic(err)
 	}
 
-	fmt.Println("Sum of largest four-digit joltages:", sumJoltages)
+	fmt.Println("Sum of largest twelve-digit joltages:", sumJoltages)
 }
 
 func main() {
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"math/big"
+	"strings"
+)
+
+// Selection records which batteries of a bank were switched on.
+// Indices are positions in Bank, in increasing order.
+type Selection struct {
+	Bank    string
+	Indices []int
+}
+
+// Digits returns the selected digits in bank order.
+func (s Selection) Digits() string {
+	digits := make([]byte, len(s.Indices))
+	for i, idx := range s.Indices {
+		digits[i] = s.Bank[idx]
+	}
+	return string(digits)
+}
+
+// Value returns the joltage of the selection; an empty selection is 0.
+func (s Selection) Value() *big.Int {
+	value := new(big.Int)
+	if len(s.Indices) > 0 {
+		value.SetString(s.Digits(), 10)
+	}
+	return value
+}
+
+// Render returns the bank with the selected digits wrapped in brackets, e.g. "8[1]1[9]".
+func (s Selection) Render() string {
+	var sb strings.Builder
+	next := 0
+	for i := 0; i < len(s.Bank); i++ {
+		if next < len(s.Indices) && s.Indices[next] == i {
+			sb.WriteByte('[')
+			sb.WriteByte(s.Bank[i])
+			sb.WriteByte(']')
+			next++
+			continue
+		}
+		sb.WriteByte(s.Bank[i])
+	}
+	return sb.String()
+}
@@ -0,0 +1,234 @@
+package main
+
+import "fmt"
+
+// A Selector picks exactly k batteries from a bank according to some objective.
+type Selector interface {
+	Select(bank string, k int) (Selection, error)
+}
+
+// LargestSelector picks the largest k-digit number, see SelectLargest.
+type LargestSelector struct{}
+
+func (LargestSelector) Select(bank string, k int) (Selection, error) {
+	return SelectLargest(bank, k)
+}
+
+// SmallestSelector picks the smallest k-digit number that does not start with 0.
+type SmallestSelector struct{}
+
+func (SmallestSelector) Select(bank string, k int) (Selection, error) {
+	return selectByDP(bank, k, smallestObjective{})
+}
+
+// DivisibleSelector picks the largest k-digit number divisible by Modulus.
+// The search keeps one state per remainder, so its time and memory grow linearly with
+// Modulus; moduli that would need more than maxDPCells cells are rejected.
+type DivisibleSelector struct {
+	Modulus int
+}
+
+func (s DivisibleSelector) Select(bank string, k int) (Selection, error) {
+	if s.Modulus <= 0 {
+		return Selection{}, fmt.Errorf("modulus must be positive, got %d", s.Modulus)
+	}
+	return selectByDP(bank, k, divisibleObjective{modulus: s.Modulus})
+}
+
+// DigitSumCapSelector picks the largest k-digit number whose digit sum is below Cap.
+type DigitSumCapSelector struct {
+	Cap int
+}
+
+func (s DigitSumCapSelector) Select(bank string, k int) (Selection, error) {
+	if s.Cap <= 0 {
+		return Selection{}, fmt.Errorf("digit sum cap must be positive, got %d", s.Cap)
+	}
+	// k digits sum to at most 9k, so a larger cap never binds and only costs states
+	return selectByDP(bank, k, digitSumCapObjective{cap: min(s.Cap, 9*k+1)})
+}
+
+// GapSelector picks the largest k-digit number whose batteries form at most MaxGaps+1
+// contiguous blocks, i.e. there are at most MaxGaps runs of skipped batteries between
+// chosen ones. Batteries skipped before the first or after the last choice don't count.
+type GapSelector struct {
+	MaxGaps int
+}
+
+func (s GapSelector) Select(bank string, k int) (Selection, error) {
+	if s.MaxGaps < 0 {
+		return Selection{}, fmt.Errorf("gap limit must not be negative, got %d", s.MaxGaps)
+	}
+	return selectByDP(bank, k, gapObjective{maxGaps: s.MaxGaps})
+}
+
+// objective describes a selection problem as a small automaton that walks the bank
+// position by position, either taking or skipping each battery.
+// States are numbered 0..states()-1 and the walk starts in state 0.
+type objective interface {
+	states() int
+	// maximize tells whether we want the largest or the smallest number
+	maximize() bool
+	// skip returns the state after skipping the current battery
+	skip(state int) (int, bool)
+	// take returns the state after taking digit d as the chosen-th digit (0 based)
+	take(state int, chosen int, d int) (int, bool)
+	accept(state int) bool
+}
+
+type smallestObjective struct{}
+
+func (smallestObjective) states() int                { return 1 }
+func (smallestObjective) maximize() bool             { return false }
+func (smallestObjective) skip(state int) (int, bool) { return state, true }
+func (smallestObjective) accept(state int) bool      { return true }
+func (smallestObjective) take(state int, chosen int, d int) (int, bool) {
+	return state, chosen > 0 || d != 0
+}
+
+// the state is the remainder of the digits taken so far
+type divisibleObjective struct {
+	modulus int
+}
+
+func (o divisibleObjective) states() int              { return o.modulus }
+func (divisibleObjective) maximize() bool             { return true }
+func (divisibleObjective) skip(state int) (int, bool) { return state, true }
+func (divisibleObjective) accept(state int) bool      { return state == 0 }
+func (o divisibleObjective) take(state int, _ int, d int) (int, bool) {
+	return (state*10 + d) % o.modulus, true
+}
+
+// the state is the digit sum so far
+type digitSumCapObjective struct {
+	cap int
+}
+
+func (o digitSumCapObjective) states() int              { return o.cap }
+func (digitSumCapObjective) maximize() bool             { return true }
+func (digitSumCapObjective) skip(state int) (int, bool) { return state, true }
+func (digitSumCapObjective) accept(state int) bool      { return true }
+func (o digitSumCapObjective) take(state int, _ int, d int) (int, bool) {
+	return state + d, state+d < o.cap
+}
+
+// the state is gaps*3 + phase
+type gapObjective struct {
+	maxGaps int
+}
+
+const (
+	gapNotStarted = iota // nothing taken yet
+	gapTaking            // the previous battery was taken
+	gapSkipping          // inside a run of skipped batteries after the first choice
+)
+
+func (o gapObjective) states() int         { return (o.maxGaps + 1) * 3 }
+func (gapObjective) maximize() bool        { return true }
+func (gapObjective) accept(state int) bool { return true }
+func (gapObjective) skip(state int) (int, bool) {
+	gaps, phase := state/3, state%3
+	if phase == gapTaking {
+		phase = gapSkipping
+	}
+	return gaps*3 + phase, true
+}
+func (o gapObjective) take(state int, _ int, _ int) (int, bool) {
+	gaps, phase := state/3, state%3
+	if phase == gapSkipping {
+		gaps++
+	}
+	return gaps*3 + gapTaking, gaps <= o.maxGaps
+}
+
+// dpCell is the best way to finish a selection from some (position, chosen, state)
+type dpCell struct {
+	feasible bool
+	digits   string // the best remaining digits
+	take     bool   // whether the current position is taken on the best path
+}
+
+// maxDPCells bounds the size of the table selectByDP builds
+const maxDPCells = 1 << 22
+
+// selectByDP runs a digit DP over (position, digits chosen, objective state), computing
+// from the back of the bank the best remaining digits for every cell. Since every
+// candidate has exactly k digits, comparing them as strings compares them as numbers.
+func selectByDP(bank string, k int, obj objective) (Selection, error) {
+	if err := validateBank(bank, k); err != nil {
+		return Selection{}, err
+	}
+
+	n := len(bank)
+	states := obj.states()
+	if states > maxDPCells/((n+1)*(k+1)) {
+		return Selection{}, fmt.Errorf("objective has too many states (%d) to pick %d digits from a %d-digit bank", states, k, n)
+	}
+	// best[i][j][s]: positions i.. are still open, j digits chosen, automaton in state s
+	best := make([][][]dpCell, n+1)
+	for i := range best {
+		best[i] = make([][]dpCell, k+1)
+		for j := range best[i] {
+			best[i][j] = make([]dpCell, states)
+		}
+	}
+	for s := 0; s < states; s++ {
+		if obj.accept(s) {
+			best[n][k][s] = dpCell{feasible: true}
+		}
+	}
+
+	for i := n - 1; i >= 0; i-- {
+		d := int(bank[i] - '0')
+		for j := 0; j <= k; j++ {
+			for s := 0; s < states; s++ {
+				cell := dpCell{}
+				if next, ok := obj.skip(s); ok && best[i+1][j][next].feasible {
+					cell = dpCell{feasible: true, digits: best[i+1][j][next].digits}
+				}
+				if j < k {
+					if next, ok := obj.take(s, j, d); ok && best[i+1][j+1][next].feasible {
+						digits := string(bank[i]) + best[i+1][j+1][next].digits
+						if !cell.feasible || (obj.maximize() && digits > cell.digits) || (!obj.maximize() && digits < cell.digits) {
+							cell = dpCell{feasible: true, digits: digits, take: true}
+						}
+					}
+				}
+				best[i][j][s] = cell
+			}
+		}
+	}
+
+	if !best[0][0][0].feasible {
+		return Selection{}, fmt.Errorf("no selection of %d digits from bank %q meets the objective", k, bank)
+	}
+
+	// follow the recorded choices to recover the positions
+	indices := make([]int, 0, k)
+	j, s := 0, 0
+	for i := 0; i < n; i++ {
+		if best[i][j][s].take {
+			indices = append(indices, i)
+			s, _ = obj.take(s, j, int(bank[i]-'0'))
+			j++
+		} else {
+			s, _ = obj.skip(s)
+		}
+	}
+	return Selection{Bank: bank, Indices: indices}, nil
+}
+
+func validateBank(bank string, k int) error {
+	if k < 0 {
+		return fmt.Errorf("cannot pick %d digits", k)
+	}
+	if len(bank) < k {
+		return fmt.Errorf("bank %q has less than %d digits", bank, k)
+	}
+	for i := 0; i < len(bank); i++ {
+		if bank[i] < '0' || bank[i] > '9' {
+			return fmt.Errorf("bank %q has non-digit %q at position %d", bank, bank[i], i)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// bruteForce tries every k-subset of the bank and keeps the best one that passes the check
+func bruteForce(bank string, k int, maximize bool, check func(indices []int) bool) (string, bool) {
+	best, found := "", false
+	indices := make([]int, 0, k)
+	var walk func(start int)
+	walk = func(start int) {
+		if len(indices) == k {
+			if !check(indices) {
+				return
+			}
+			digits := Selection{Bank: bank, Indices: indices}.Digits()
+			if !found || (maximize && digits > best) || (!maximize && digits < best) {
+				best, found = digits, true
+			}
+			return
+		}
+		for i := start; i < len(bank); i++ {
+			indices = append(indices, i)
+			walk(i + 1)
+			indices = indices[:len(indices)-1]
+		}
+	}
+	walk(0)
+	return best, found
+}
+
+func TestSelectorsAgainstBruteForce(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+
+	digitAt := func(bank string, i int) int { return int(bank[i] - '0') }
+
+	for round := 0; round < 300; round++ {
+		n := 1 + rng.Intn(9)
+		b := make([]byte, n)
+		for i := range b {
+			b[i] = byte('0' + rng.Intn(10))
+		}
+		bank := string(b)
+		k := 1 + rng.Intn(n)
+		modulus := 1 + rng.Intn(12)
+		sumCap := 1 + rng.Intn(30)
+		maxGaps := rng.Intn(3)
+
+		cases := []struct {
+			name     string
+			selector Selector
+			maximize bool
+			check    func(indices []int) bool
+		}{
+			{"largest", LargestSelector{}, true, func([]int) bool { return true }},
+			{"smallest", SmallestSelector{}, false, func(indices []int) bool {
+				return bank[indices[0]] != '0'
+			}},
+			{"divisible", DivisibleSelector{Modulus: modulus}, true, func(indices []int) bool {
+				rem := 0
+				for _, i := range indices {
+					rem = (rem*10 + digitAt(bank, i)) % modulus
+				}
+				return rem == 0
+			}},
+			{"digit sum", DigitSumCapSelector{Cap: sumCap}, true, func(indices []int) bool {
+				sum := 0
+				for _, i := range indices {
+					sum += digitAt(bank, i)
+				}
+				return sum < sumCap
+			}},
+			{"gaps", GapSelector{MaxGaps: maxGaps}, true, func(indices []int) bool {
+				gaps := 0
+				for i := 1; i < len(indices); i++ {
+					if indices[i] != indices[i-1]+1 {
+						gaps++
+					}
+				}
+				return gaps <= maxGaps
+			}},
+		}
+
+		for _, c := range cases {
+			expected, found := bruteForce(bank, k, c.maximize, c.check)
+			selection, err := c.selector.Select(bank, k)
+			if !found {
+				if err == nil {
+					t.Errorf("%s on %s (k=%d): expected no selection, got %v", c.name, bank, k, selection.Indices)
+				}
+				continue
+			}
+			if err != nil {
+				t.Errorf("%s on %s (k=%d): unexpected error %v", c.name, bank, k, err)
+				continue
+			}
+			if selection.Digits() != expected {
+				t.Errorf("%s on %s (k=%d): expected %s, got %s", c.name, bank, k, expected, selection.Digits())
+			}
+			if !c.check(selection.Indices) {
+				t.Errorf("%s on %s (k=%d): indices %v violate the objective", c.name, bank, k, selection.Indices)
+			}
+		}
+	}
+}
+
+func TestSelectorExamples(t *testing.T) {
+	tests := []struct {
+		selector Selector
+		bank     string
+		k        int
+		expected string
+	}{
+		{SmallestSelector{}, "0301", 2, "30"},
+		{SmallestSelector{}, "90210", 3, "210"},
+		{DivisibleSelector{Modulus: 7}, "987654321111111", 3, "987"},
+		{DivisibleSelector{Modulus: 2}, "9999", 2, ""},
+		{DigitSumCapSelector{Cap: 10}, "987654321", 2, "81"},
+		{DigitSumCapSelector{Cap: 1 << 40}, "987654321111111", 12, "987654321111"},
+		{DivisibleSelector{Modulus: 1 << 40}, "987654321111111", 12, ""},
+		{GapSelector{MaxGaps: 0}, "1919191", 3, "919"},
+		{GapSelector{MaxGaps: 1}, "1919191", 3, "991"},
+	}
+
+	for _, test := range tests {
+		selection, err := test.selector.Select(test.bank, test.k)
+		if test.expected == "" {
+			if err == nil {
+				t.Errorf("%T on %s: expected an error, got %s", test.selector, test.bank, selection.Digits())
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%T on %s: unexpected error %v", test.selector, test.bank, err)
+			continue
+		}
+		if selection.Digits() != test.expected {
+			t.Errorf("%T on %s: expected %s, got %s", test.selector, test.bank, test.expected, selection.Digits())
+		}
+	}
+}
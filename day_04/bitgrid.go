@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"math/bits"
+)
+
+// BitGrid stores one bit per cell, set where there is a roll.
+// Bit col%64 of word col/64 in a row holds column col; bits past nCols are always zero.
+type BitGrid struct {
+	nRows int
+	nCols int
+	words int // words per row
+	rows  [][]uint64
+}
+
+func NewBitGrid(nRows, nCols int) *BitGrid {
+	words := (nCols + 63) / 64
+	rows := make([][]uint64, nRows)
+	for i := range rows {
+		rows[i] = make([]uint64, words)
+	}
+	return &BitGrid{nRows: nRows, nCols: nCols, words: words, rows: rows}
+}
+
+func (b *BitGrid) Has(row, col int) bool {
+	return b.rows[row][col/64]&(1<<(col%64)) != 0
+}
+
+func (b *BitGrid) Set(row, col int) {
+	b.rows[row][col/64] |= 1 << (col % 64)
+}
+
+func (b *BitGrid) Clear(row, col int) {
+	b.rows[row][col/64] &^= 1 << (col % 64)
+}
+
+// Count returns the number of rolls on the grid.
+func (b *BitGrid) Count() int {
+	n := 0
+	for _, row := range b.rows {
+		for _, w := range row {
+			n += bits.OnesCount64(w)
+		}
+	}
+	return n
+}
+
+// Bitboard returns a copy of the grid backed by a BitGrid. Any cell that is not a roll becomes '.'.
+func (g *Grid) Bitboard() Grid {
+	b := NewBitGrid(g.nRows, g.nCols)
+	for row := 0; row < g.nRows; row++ {
+		for col := 0; col < g.nCols; col++ {
+			if g.Get(row, col) == rollMarker {
+				b.Set(row, col)
+			}
+		}
+	}
+	return Grid{nRows: g.nRows, nCols: g.nCols, bits: b}
+}
+
+// ParseBitGrid reads the map line by line straight into a bitboard, without
+// keeping a byte per cell around, for very large maps.
+func ParseBitGrid(r io.Reader) Grid {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<30)
+
+	rows := make([][]uint64, 0)
+	nCols := 0
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		if nCols == 0 {
+			nCols = len(line)
+		}
+		row := make([]uint64, (nCols+63)/64)
+		for col := 0; col < nCols && col < len(line); col++ {
+			if line[col] == rollMarker {
+				row[col/64] |= 1 << (col % 64)
+			}
+		}
+		rows = append(rows, row)
+	}
+	if err := scanner.Err(); err != nil {
+		panic(err)
+	}
+
+	b := &BitGrid{nRows: len(rows), nCols: nCols, words: (nCols + 63) / 64, rows: rows}
+	return Grid{nRows: b.nRows, nCols: b.nCols, bits: b}
+}
+
+// shiftedFromLeft moves every cell one column to the right, so that bit col holds
+// what was at col-1: the neighbor on the left.
+func shiftedFromLeft(row []uint64, out []uint64) {
+	var carry uint64
+	for i, w := range row {
+		out[i] = w<<1 | carry
+		carry = w >> 63
+	}
+}
+
+// shiftedFromRight moves every cell one column to the left, so that bit col holds
+// what was at col+1: the neighbor on the right.
+func shiftedFromRight(row []uint64, out []uint64) {
+	for i := range row {
+		var carry uint64
+		if i+1 < len(row) {
+			carry = row[i+1] << 63
+		}
+		out[i] = row[i]>>1 | carry
+	}
+}
+
+// LiftableMask returns, for every row, the rolls that have fewer than four rolls among their 8 neighbors.
+//
+// The neighbor count is kept bit-sliced: ones and twos are the low bits of the count for all 64
+// cells of a word at once, and fours is set once a cell has seen four or more neighbors. Adding
+// a neighbor word is then a handful of bitwise operations instead of 64 lookups.
+func (b *BitGrid) LiftableMask() [][]uint64 {
+	mask := make([][]uint64, b.nRows)
+	left := make([]uint64, b.words)
+	right := make([]uint64, b.words)
+	ones := make([]uint64, b.words)
+	twos := make([]uint64, b.words)
+	fours := make([]uint64, b.words)
+
+	add := func(x []uint64) {
+		for i, w := range x {
+			carry := ones[i] & w
+			ones[i] ^= w
+			carry2 := twos[i] & carry
+			twos[i] ^= carry
+			fours[i] |= carry2
+		}
+	}
+
+	for row := 0; row < b.nRows; row++ {
+		clear(ones)
+		clear(twos)
+		clear(fours)
+		for dr := -1; dr <= 1; dr++ {
+			r := row + dr
+			if r < 0 || r >= b.nRows {
+				continue
+			}
+			shiftedFromLeft(b.rows[r], left)
+			shiftedFromRight(b.rows[r], right)
+			add(left)
+			add(right)
+			if dr != 0 {
+				add(b.rows[r])
+			}
+		}
+
+		mask[row] = make([]uint64, b.words)
+		for i := range mask[row] {
+			mask[row][i] = b.rows[row][i] &^ fours[i]
+		}
+	}
+	return mask
+}
+
+// RemoveMask clears every cell set in mask and returns how many rolls were removed.
+func (b *BitGrid) RemoveMask(mask [][]uint64) int {
+	removed := 0
+	for row := range mask {
+		for i, w := range mask[row] {
+			removed += bits.OnesCount64(b.rows[row][i] & w)
+			b.rows[row][i] &^= w
+		}
+	}
+	return removed
+}
+
+// PeelRounds removes liftable rolls round by round, a whole word at a time, and returns how
+// many rolls went in each round. It needs no memory per cell beyond the bitboard itself.
+func (b *BitGrid) PeelRounds() []int {
+	removed := make([]int, 0)
+	for {
+		n := b.RemoveMask(b.LiftableMask())
+		if n == 0 {
+			return removed
+		}
+		removed = append(removed, n)
+	}
+}
+
+func maskPositions(mask [][]uint64) []Position {
+	positions := make([]Position, 0)
+	for row := range mask {
+		for i, w := range mask[row] {
+			for w != 0 {
+				bit := bits.TrailingZeros64(w)
+				positions = append(positions, Position{row: row, col: i*64 + bit})
+				w &= w - 1
+			}
+		}
+	}
+	return positions
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func randomGrid(rng *rand.Rand, nRows, nCols int, density int) Grid {
+	data := make([][]byte, nRows)
+	for r := range data {
+		data[r] = make([]byte, nCols)
+		for c := range data[r] {
+			data[r][c] = '.'
+			if rng.Intn(100) < density {
+				data[r][c] = rollMarker
+			}
+		}
+	}
+	return Grid{nRows: nRows, nCols: nCols, data: data}
+}
+
+func TestBitboardMatchesByteGrid(t *testing.T) {
+	rng := rand.New(rand.NewSource(34))
+	// widths around word boundaries
+	for _, nCols := range []int{1, 5, 63, 64, 65, 127, 128, 130} {
+		grid := randomGrid(rng, 1+rng.Intn(20), nCols, 65)
+		bitboard := grid.Bitboard()
+
+		for row := 0; row < grid.nRows; row++ {
+			for col := 0; col < grid.nCols; col++ {
+				if grid.Get(row, col) != bitboard.Get(row, col) {
+					t.Fatalf("%d columns: cell (%d, %d) differs", nCols, row, col)
+				}
+			}
+		}
+
+		expected := grid.FindLiftableRolls()
+		got := bitboard.FindLiftableRolls()
+		if !reflect.DeepEqual(expected, got) {
+			t.Errorf("%d columns: expected liftable %v, got %v", nCols, expected, got)
+		}
+
+		if rounds := bitboard.bits.PeelRounds(); !reflect.DeepEqual(rounds, grid.Peel().Removed) {
+			t.Errorf("%d columns: expected rounds %v, got %v", nCols, grid.Peel().Removed, rounds)
+		}
+	}
+}
+
+func TestParseBitGrid(t *testing.T) {
+	grid := ParseBitGrid(strings.NewReader(exampleGrid))
+	if grid.nRows != 10 || grid.nCols != 10 {
+		t.Fatalf("expected a 10x10 grid, got %dx%d", grid.nRows, grid.nCols)
+	}
+	rounds := grid.bits.PeelRounds()
+	if len(rounds) == 0 || rounds[0] != 13 {
+		t.Errorf("expected 13 rolls in the first round, got %v", rounds)
+	}
+	total := 0
+	for _, n := range rounds {
+		total += n
+	}
+	if total != 43 {
+		t.Errorf("expected 43 rolls in total, got %d", total)
+	}
+}
+
+const benchRows, benchCols = 2000, 2000
+
+func BenchmarkFindLiftableBytes(b *testing.B) {
+	grid := randomGrid(rand.New(rand.NewSource(1)), benchRows, benchCols, 70)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		grid.FindLiftableRolls()
+	}
+}
+
+func BenchmarkFindLiftableBits(b *testing.B) {
+	grid := randomGrid(rand.New(rand.NewSource(1)), benchRows, benchCols, 70)
+	bitboard := grid.Bitboard()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		bitboard.bits.LiftableMask()
+	}
+}
+
+func BenchmarkPeelBytes(b *testing.B) {
+	grid := randomGrid(rand.New(rand.NewSource(1)), benchRows, benchCols, 70)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		grid.Peel()
+	}
+}
+
+func BenchmarkPeelBits(b *testing.B) {
+	grid := randomGrid(rand.New(rand.NewSource(1)), benchRows, benchCols, 70)
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		bitboard := grid.Bitboard()
+		b.StartTimer()
+		bitboard.bits.PeelRounds()
+	}
+}
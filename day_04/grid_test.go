@@ -0,0 +1,90 @@
+package main
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+const exampleGrid = `..@@.@@@@.
+@@@.@.@.@@
+@@@@@.@.@@
+@.@@@@..@.
+@@.@@@@.@@
+.@@@@@@@.@
+.@.@.@.@@@
+@.@@@.@@@@
+.@@@@@@@@.
+@.@.@@@.@.
+`
+
+// peelByRescanning is the straightforward version of Peel: rescan the whole grid every round
+func peelByRescanning(g Grid) [][]int {
+	rounds := make([][]int, g.nRows)
+	for row := range rounds {
+		rounds[row] = make([]int, g.nCols)
+		for col := range rounds[row] {
+			if g.Get(row, col) == rollMarker {
+				rounds[row][col] = NeverRemoved
+			}
+		}
+	}
+	for round := 1; ; round++ {
+		liftable := g.FindLiftableRolls()
+		if len(liftable) == 0 {
+			return rounds
+		}
+		for _, pos := range liftable {
+			rounds[pos.row][pos.col] = round
+			g.RemovePaperRoll(pos.row, pos.col)
+		}
+	}
+}
+
+func copyGrid(g Grid) Grid {
+	data := make([][]byte, g.nRows)
+	for i := range data {
+		data[i] = append([]byte(nil), g.data[i]...)
+	}
+	return Grid{nRows: g.nRows, nCols: g.nCols, data: data}
+}
+
+func TestPeelExample(t *testing.T) {
+	grid := ParseInput(strings.NewReader(exampleGrid))
+	peeled := grid.Peel()
+
+	if len(peeled.Removed) == 0 || peeled.Removed[0] != 13 {
+		t.Errorf("expected 13 rolls in the first round, got %v", peeled.Removed)
+	}
+	if peeled.Total() != 43 {
+		t.Errorf("expected 43 rolls in total, got %d", peeled.Total())
+	}
+}
+
+func TestPeelMatchesRescanning(t *testing.T) {
+	rng := rand.New(rand.NewSource(4))
+	for round := 0; round < 50; round++ {
+		nRows, nCols := 1+rng.Intn(15), 1+rng.Intn(15)
+		data := make([][]byte, nRows)
+		for r := range data {
+			data[r] = make([]byte, nCols)
+			for c := range data[r] {
+				data[r][c] = '.'
+				if rng.Intn(10) < 7 {
+					data[r][c] = rollMarker
+				}
+			}
+		}
+		grid := Grid{nRows: nRows, nCols: nCols, data: data}
+
+		expected := peelByRescanning(copyGrid(grid))
+		got := grid.Peel().Rounds
+		for r := 0; r < nRows; r++ {
+			for c := 0; c < nCols; c++ {
+				if got[r][c] != expected[r][c] {
+					t.Fatalf("grid %d: cell (%d, %d) expected round %d, got %d", round, r, c, expected[r][c], got[r][c])
+				}
+			}
+		}
+	}
+}
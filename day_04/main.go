@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"io"
 	"os"
@@ -11,6 +12,7 @@ type Grid struct {
 	nRows int
 	nCols int
 	data  [][]byte
+	bits  *BitGrid // when set, the grid is stored as a bitboard and data is nil
 }
 
 type Position struct {
@@ -20,17 +22,34 @@ type Position struct {
 
 const rollMarker byte = '@'
 
+// a roll can be lifted when fewer than this many neighbors are rolls
+const liftThreshold = 4
+
 func (g *Grid) Get(row, col int) byte {
+	if g.bits != nil {
+		if g.bits.Has(row, col) {
+			return rollMarker
+		}
+		return '.'
+	}
 	return g.data[row][col]
 }
 
 // Remove the roll of paper at (row, col)
 func (g *Grid) RemovePaperRoll(row, col int) {
+	if g.bits != nil {
+		g.bits.Clear(row, col)
+		return
+	}
 	g.data[row][col] = '.'
 }
 
 // Find Liftable Rolls
 func (g *Grid) FindLiftableRolls() []Position {
+	if g.bits != nil {
+		return maskPositions(g.bits.LiftableMask())
+	}
+
 	liftable := make([]Position, 0)
 	for row := 0; row < g.nRows; row++ {
 		for col := 0; col < g.nCols; col++ {
@@ -51,7 +70,11 @@ func (g *Grid) CanBeForkLifted(row, col int) bool {
 		return false
 	}
 
-	neighboringRolls := 0
+	return g.countNeighboringRolls(row, col) < liftThreshold
+}
+
+// call f for each of the up to 8 adjacent positions inside the grid
+func (g *Grid) forEachNeighbor(row, col int, f func(r, c int)) {
 	for dr := -1; dr <= 1; dr++ {
 		for dc := -1; dc <= 1; dc++ {
 			if dr == 0 && dc == 0 {
@@ -60,13 +83,20 @@ func (g *Grid) CanBeForkLifted(row, col int) bool {
 			r := row + dr
 			c := col + dc
 			if r >= 0 && r < g.nRows && c >= 0 && c < g.nCols {
-				if g.Get(r, c) == rollMarker {
-					neighboringRolls++
-				}
+				f(r, c)
 			}
 		}
 	}
-	return neighboringRolls < 4
+}
+
+func (g *Grid) countNeighboringRolls(row, col int) int {
+	neighboringRolls := 0
+	g.forEachNeighbor(row, col, func(r, c int) {
+		if g.Get(r, c) == rollMarker {
+			neighboringRolls++
+		}
+	})
+	return neighboringRolls
 }
 
 func ParseInput(r io.Reader) Grid {
@@ -75,7 +105,7 @@ func ParseInput(r io.Reader) Grid {
 		panic(err)
 	}
 
-	lines := strings.Split(string(data), "\n")
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
 	grid := make([][]byte, len(lines))
 	for i, line := range lines {
 		grid[i] = []byte(line)
@@ -87,41 +117,148 @@ func ParseInput(r io.Reader) Grid {
 	}
 }
 
-func Part1() {
+// removedPerRound reads the map from stdin and returns how many rolls are lifted in each round.
+// The bitboard backend only supports the default rules.
+func removedPerRound(rules RuleConfig, bitboard bool) []int {
+	if bitboard {
+		grid := ParseBitGrid(os.Stdin)
+		return grid.bits.PeelRounds()
+	}
 
 	grid := ParseInput(os.Stdin)
+	peeled, err := grid.PeelWith(rules)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	return peeled.Removed
+}
 
-	fmt.Println(grid)
+func Part1(rules RuleConfig, bitboard bool) {
+	removed := removedPerRound(rules, bitboard)
 	movableRolls := 0
-	for row := 0; row < grid.nRows; row++ {
-		for col := 0; col < grid.nCols; col++ {
-			if grid.CanBeForkLifted(row, col) {
-				movableRolls++
-			}
-		}
+	if len(removed) > 0 {
+		movableRolls = removed[0]
 	}
 
 	fmt.Println("Number of rolls that can be forklifted:", movableRolls)
 }
 
-func Part2() {
+func Part2(rules RuleConfig, bitboard bool) {
+	removed := removedPerRound(rules, bitboard)
+	total := 0
+	for _, n := range removed {
+		total += n
+	}
+	fmt.Println("Rolls removed per round:", removed)
+	fmt.Println("Total number of rolls that can be forklifted:", total)
+}
+
+// VisualOptions selects the outputs written by Visualize; empty paths are skipped.
+type VisualOptions struct {
+	ANSI    bool
+	GIFPath string
+	PNGPath string
+	Scale   int
+	Delay   int // GIF frame delay in 100ths of a second
+}
 
+// Visualize peels the map read from stdin and exports the removal rounds.
+func Visualize(rules RuleConfig, opts VisualOptions) error {
 	grid := ParseInput(os.Stdin)
-	totalMovableRolls := 0
-	for {
-		movableRolls := grid.FindLiftableRolls()
-		if len(movableRolls) == 0 {
-			break
+	peeled, err := grid.PeelWith(rules)
+	if err != nil {
+		return err
+	}
+
+	if opts.ANSI {
+		if err := WriteANSIFrames(os.Stdout, peeled); err != nil {
+			return err
+		}
+	}
+	write := func(path string, export func(io.Writer) error) error {
+		if path == "" {
+			return nil
+		}
+		f, err := os.Create(path)
+		if err != nil {
+			return err
 		}
-		totalMovableRolls += len(movableRolls)
-		for _, pos := range movableRolls {
-			grid.RemovePaperRoll(pos.row, pos.col)
+		if err := export(f); err != nil {
+			f.Close()
+			return err
 		}
+		return f.Close()
 	}
-	fmt.Println("Total number of rolls that can be forklifted:", totalMovableRolls)
+	if err := write(opts.GIFPath, func(w io.Writer) error { return WriteGIF(w, peeled, opts.Scale, opts.Delay) }); err != nil {
+		return err
+	}
+	if err := write(opts.PNGPath, func(w io.Writer) error { return WritePNGHeatmap(w, peeled, opts.Scale) }); err != nil {
+		return err
+	}
+
+	fmt.Println("Total number of rolls that can be forklifted:", peeled.Total())
+	return nil
 }
 
 func main() {
-	//Part1()
-	Part2()
+	defaults := DefaultRules()
+	part := flag.Int("part", 2, "which part to solve (1 or 2)")
+	neighborhood := flag.String("neighborhood", "moore", "moore, vonneumann or custom offsets like '-1,0;1,0'")
+	radius := flag.Int("radius", 1, "radius of the moore or vonneumann neighborhood")
+	threshold := flag.Int("threshold", defaults.Threshold, "neighbor count the comparison is made against")
+	compare := flag.String("compare", string(defaults.Compare), "comparison of the neighbor count with the threshold: < <= == != >= >")
+	wrap := flag.Bool("wrap", false, "wrap around the grid edges (toroidal grid)")
+	sequential := flag.Bool("sequential", false, "remove rolls one by one during a row-major sweep instead of all at once")
+	marker := flag.String("marker", string(defaults.Marker), "character marking a roll")
+	bitboard := flag.Bool("bitboard", false, "store the map as a bitboard, for very large maps (default rules only)")
+	var visual VisualOptions
+	flag.BoolVar(&visual.ANSI, "ansi", false, "print every removal round as an ANSI colored frame")
+	flag.StringVar(&visual.GIFPath, "gif", "", "write an animated GIF of the removal rounds to this file")
+	flag.StringVar(&visual.PNGPath, "png", "", "write a PNG heatmap of the removal rounds to this file")
+	flag.IntVar(&visual.Scale, "scale", 4, "pixels per cell in the GIF and PNG output")
+	flag.IntVar(&visual.Delay, "delay", 50, "GIF frame delay in 100ths of a second")
+	flag.Parse()
+
+	if *bitboard {
+		flag.Visit(func(f *flag.Flag) {
+			if f.Name != "part" && f.Name != "bitboard" {
+				fmt.Fprintf(os.Stderr, "-%s cannot be combined with -bitboard\n", f.Name)
+				os.Exit(1)
+			}
+		})
+	}
+
+	rules := defaults
+	var err error
+	rules.Neighborhood, err = ParseNeighborhood(*neighborhood, *radius)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if len(*marker) != 1 {
+		fmt.Fprintln(os.Stderr, "marker must be a single character")
+		os.Exit(1)
+	}
+	rules.Marker = (*marker)[0]
+	rules.Threshold = *threshold
+	rules.Compare = Comparison(*compare)
+	rules.Wrap = *wrap
+	if *sequential {
+		rules.Removal = Sequential
+	}
+
+	if visual.ANSI || visual.GIFPath != "" || visual.PNGPath != "" {
+		if err := Visualize(rules, visual); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *part == 1 {
+		Part1(rules, *bitboard)
+	} else {
+		Part2(rules, *bitboard)
+	}
 }
@@ -0,0 +1,33 @@
+package main
+
+// NeverRemoved marks a roll that stays in the pile however many rounds are run.
+// Cells without a roll are 0 in the removal rounds, removed rolls are numbered from 1.
+const NeverRemoved = -1
+
+// PeelResult describes how the pile of rolls is taken apart round by round.
+type PeelResult struct {
+	// Rounds[row][col] is the round in which the roll at (row, col) is removed
+	Rounds [][]int
+	// Removed[i] is the number of rolls removed in round i+1
+	Removed []int
+}
+
+// Total returns the number of rolls that are eventually removed.
+func (p PeelResult) Total() int {
+	total := 0
+	for _, n := range p.Removed {
+		total += n
+	}
+	return total
+}
+
+// Peel removes liftable rolls round by round, like repeatedly calling FindLiftableRolls,
+// but in O(cells): it keeps the number of neighboring rolls for every roll and, when a roll
+// is removed, only looks at its neighbors again. The grid itself is left untouched.
+func (g *Grid) Peel() PeelResult {
+	result, err := g.PeelWith(DefaultRules())
+	if err != nil {
+		panic(err) // the default rules are always valid
+	}
+	return result
+}
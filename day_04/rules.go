@@ -0,0 +1,363 @@
+package main
+
+import (
+	"container/heap"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Offset is a relative position of a neighbor.
+type Offset struct {
+	dr int
+	dc int
+}
+
+// Neighborhood is the set of offsets whose rolls are counted for a cell.
+type Neighborhood []Offset
+
+// MooreNeighborhood contains all cells within Chebyshev distance radius (the 8 adjacent cells for radius 1).
+func MooreNeighborhood(radius int) Neighborhood {
+	n := make(Neighborhood, 0)
+	for dr := -radius; dr <= radius; dr++ {
+		for dc := -radius; dc <= radius; dc++ {
+			if dr != 0 || dc != 0 {
+				n = append(n, Offset{dr: dr, dc: dc})
+			}
+		}
+	}
+	return n
+}
+
+// VonNeumannNeighborhood contains all cells within Manhattan distance radius (up, down, left, right for radius 1).
+func VonNeumannNeighborhood(radius int) Neighborhood {
+	n := make(Neighborhood, 0)
+	for dr := -radius; dr <= radius; dr++ {
+		for dc := -radius; dc <= radius; dc++ {
+			if (dr != 0 || dc != 0) && abs(dr)+abs(dc) <= radius {
+				n = append(n, Offset{dr: dr, dc: dc})
+			}
+		}
+	}
+	return n
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// ParseNeighborhood understands "moore", "vonneumann" (both using the given radius)
+// and a custom list of "dr,dc" offsets separated by ';', e.g. "-1,0;1,0;0,2".
+func ParseNeighborhood(spec string, radius int) (Neighborhood, error) {
+	switch spec {
+	case "moore":
+		return MooreNeighborhood(radius), nil
+	case "vonneumann", "von-neumann":
+		return VonNeumannNeighborhood(radius), nil
+	}
+
+	n := make(Neighborhood, 0)
+	for _, part := range strings.Split(spec, ";") {
+		drStr, dcStr, ok := strings.Cut(strings.TrimSpace(part), ",")
+		if !ok {
+			return nil, fmt.Errorf("invalid offset %q in neighborhood %q", part, spec)
+		}
+		dr, err := strconv.Atoi(strings.TrimSpace(drStr))
+		if err != nil {
+			return nil, fmt.Errorf("invalid offset %q in neighborhood %q: %w", part, spec, err)
+		}
+		dc, err := strconv.Atoi(strings.TrimSpace(dcStr))
+		if err != nil {
+			return nil, fmt.Errorf("invalid offset %q in neighborhood %q: %w", part, spec, err)
+		}
+		n = append(n, Offset{dr: dr, dc: dc})
+	}
+	return n, nil
+}
+
+// Comparison decides how the neighbor count is compared with the threshold.
+type Comparison string
+
+const (
+	Less           Comparison = "<"
+	LessOrEqual    Comparison = "<="
+	Equal          Comparison = "=="
+	NotEqual       Comparison = "!="
+	GreaterOrEqual Comparison = ">="
+	Greater        Comparison = ">"
+)
+
+func (c Comparison) valid() bool {
+	switch c {
+	case Less, LessOrEqual, Equal, NotEqual, GreaterOrEqual, Greater:
+		return true
+	}
+	return false
+}
+
+func (c Comparison) holds(count, threshold int) bool {
+	switch c {
+	case Less:
+		return count < threshold
+	case LessOrEqual:
+		return count <= threshold
+	case Equal:
+		return count == threshold
+	case NotEqual:
+		return count != threshold
+	case GreaterOrEqual:
+		return count >= threshold
+	case Greater:
+		return count > threshold
+	}
+	return false
+}
+
+// RemovalMode decides when removed rolls stop counting as neighbors.
+type RemovalMode int
+
+const (
+	// Simultaneous removes everything that is liftable at the start of a round at once.
+	Simultaneous RemovalMode = iota
+	// Sequential sweeps the grid row by row and removes a roll as soon as it is liftable,
+	// so later cells of the same sweep already see it gone. A round is one sweep.
+	Sequential
+)
+
+// RuleConfig describes when the forklifts can lift a roll.
+type RuleConfig struct {
+	Marker       byte
+	Neighborhood Neighborhood
+	Threshold    int
+	Compare      Comparison
+	// Wrap makes the grid toroidal, otherwise neighbors outside the grid don't exist
+	Wrap    bool
+	Removal RemovalMode
+}
+
+// DefaultRules are the rules of the puzzle: fewer than 4 rolls among the 8 adjacent cells.
+func DefaultRules() RuleConfig {
+	return RuleConfig{
+		Marker:       rollMarker,
+		Neighborhood: MooreNeighborhood(1),
+		Threshold:    liftThreshold,
+		Compare:      Less,
+		Wrap:         false,
+		Removal:      Simultaneous,
+	}
+}
+
+func (rules RuleConfig) Validate() error {
+	if len(rules.Neighborhood) == 0 {
+		return fmt.Errorf("empty neighborhood")
+	}
+	if !rules.Compare.valid() {
+		return fmt.Errorf("unknown comparison %q", rules.Compare)
+	}
+	if rules.Removal != Simultaneous && rules.Removal != Sequential {
+		return fmt.Errorf("unknown removal mode %d", rules.Removal)
+	}
+	return nil
+}
+
+// neighbor applies an offset, returning false when the result falls off a non-wrapping grid
+func (g *Grid) neighbor(row, col int, o Offset, wrap bool) (int, int, bool) {
+	r, c := row+o.dr, col+o.dc
+	if wrap {
+		r = ((r % g.nRows) + g.nRows) % g.nRows
+		c = ((c % g.nCols) + g.nCols) % g.nCols
+		return r, c, true
+	}
+	return r, c, r >= 0 && r < g.nRows && c >= 0 && c < g.nCols
+}
+
+// PeelWith removes rolls according to the rules, round by round, and reports the round in which
+// each roll goes. Like Peel it keeps neighbor counts and only re-examines cells whose count changed.
+func (g *Grid) PeelWith(rules RuleConfig) (PeelResult, error) {
+	if err := rules.Validate(); err != nil {
+		return PeelResult{}, err
+	}
+
+	p := newPeeler(g, rules)
+	if rules.Removal == Sequential {
+		p.peelSequential()
+	} else {
+		p.peelSimultaneous()
+	}
+	return p.result, nil
+}
+
+type peeler struct {
+	g      *Grid
+	rules  RuleConfig
+	counts []int // per cell, row-major
+	result PeelResult
+	// offsets pointing from a roll back to the cells that count it
+	reverse Neighborhood
+}
+
+func newPeeler(g *Grid, rules RuleConfig) *peeler {
+	p := &peeler{
+		g:       g,
+		rules:   rules,
+		counts:  make([]int, g.nRows*g.nCols),
+		result:  PeelResult{Rounds: make([][]int, g.nRows), Removed: make([]int, 0)},
+		reverse: make(Neighborhood, len(rules.Neighborhood)),
+	}
+	for i, o := range rules.Neighborhood {
+		p.reverse[i] = Offset{dr: -o.dr, dc: -o.dc}
+	}
+
+	for row := 0; row < g.nRows; row++ {
+		p.result.Rounds[row] = make([]int, g.nCols)
+		for col := 0; col < g.nCols; col++ {
+			if g.Get(row, col) == rules.Marker {
+				p.result.Rounds[row][col] = NeverRemoved
+			}
+		}
+	}
+	for row := 0; row < g.nRows; row++ {
+		for col := 0; col < g.nCols; col++ {
+			for _, o := range rules.Neighborhood {
+				if r, c, ok := g.neighbor(row, col, o, rules.Wrap); ok && g.Get(r, c) == rules.Marker {
+					p.counts[row*g.nCols+col]++
+				}
+			}
+		}
+	}
+	return p
+}
+
+func (p *peeler) present(row, col int) bool {
+	return p.result.Rounds[row][col] == NeverRemoved
+}
+
+func (p *peeler) liftable(row, col int) bool {
+	return p.present(row, col) && p.rules.Compare.holds(p.counts[row*p.g.nCols+col], p.rules.Threshold)
+}
+
+// release updates the counts after the roll at (row, col) has been removed,
+// calling affected for every roll still present whose count went down
+func (p *peeler) release(row, col int, affected func(r, c int)) {
+	for _, o := range p.reverse {
+		r, c, ok := p.g.neighbor(row, col, o, p.rules.Wrap)
+		if !ok {
+			continue
+		}
+		p.counts[r*p.g.nCols+c]--
+		if p.present(r, c) {
+			affected(r, c)
+		}
+	}
+}
+
+func (p *peeler) peelSimultaneous() {
+	g := p.g
+	candidates := make([]Position, 0)
+	for row := 0; row < g.nRows; row++ {
+		for col := 0; col < g.nCols; col++ {
+			if p.present(row, col) {
+				candidates = append(candidates, Position{row: row, col: col})
+			}
+		}
+	}
+
+	queued := make([]bool, g.nRows*g.nCols)
+	for round := 1; len(candidates) > 0; round++ {
+		// decide the whole round before removing anything
+		lifted := make([]Position, 0)
+		for _, pos := range candidates {
+			queued[pos.row*g.nCols+pos.col] = false
+			if p.liftable(pos.row, pos.col) {
+				lifted = append(lifted, pos)
+			}
+		}
+		if len(lifted) == 0 {
+			break
+		}
+		for _, pos := range lifted {
+			p.result.Rounds[pos.row][pos.col] = round
+		}
+		p.result.Removed = append(p.result.Removed, len(lifted))
+
+		// only cells whose count changed can change their mind
+		candidates = make([]Position, 0)
+		for _, pos := range lifted {
+			p.release(pos.row, pos.col, func(r, c int) {
+				if !queued[r*g.nCols+c] {
+					queued[r*g.nCols+c] = true
+					candidates = append(candidates, Position{row: r, col: c})
+				}
+			})
+		}
+	}
+}
+
+func (p *peeler) peelSequential() {
+	g := p.g
+	// the first sweep visits every roll; indices are added in order, so this is already a heap
+	current := &indexHeap{}
+	inCurrent := make([]bool, g.nRows*g.nCols)
+	inNext := make([]bool, g.nRows*g.nCols)
+	for i := 0; i < g.nRows*g.nCols; i++ {
+		if p.present(i/g.nCols, i%g.nCols) {
+			*current = append(*current, i)
+			inCurrent[i] = true
+		}
+	}
+
+	for round := 1; current.Len() > 0; round++ {
+		removed := 0
+		next := make([]int, 0)
+		for current.Len() > 0 {
+			i := heap.Pop(current).(int)
+			inCurrent[i] = false
+			row, col := i/g.nCols, i%g.nCols
+			if !p.liftable(row, col) {
+				continue
+			}
+			removed++
+			p.result.Rounds[row][col] = round
+			p.release(row, col, func(r, c int) {
+				j := r*g.nCols + c
+				switch {
+				case j > i && !inCurrent[j]:
+					// still ahead of us in this sweep
+					inCurrent[j] = true
+					heap.Push(current, j)
+				case j < i && !inNext[j]:
+					inNext[j] = true
+					next = append(next, j)
+				}
+			})
+		}
+		if removed == 0 {
+			break
+		}
+		p.result.Removed = append(p.result.Removed, removed)
+
+		for _, j := range next {
+			inNext[j] = false
+			inCurrent[j] = true
+			heap.Push(current, j)
+		}
+	}
+}
+
+// indexHeap is a min-heap of row-major cell indices
+type indexHeap []int
+
+func (h indexHeap) Len() int           { return len(h) }
+func (h indexHeap) Less(i, j int) bool { return h[i] < h[j] }
+func (h indexHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *indexHeap) Push(x any)        { *h = append(*h, x.(int)) }
+func (h *indexHeap) Pop() any {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+	return x
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// peelNaively applies the rules by rescanning the whole grid, counting neighbors from scratch
+func peelNaively(g Grid, rules RuleConfig) [][]int {
+	rounds := make([][]int, g.nRows)
+	for row := range rounds {
+		rounds[row] = make([]int, g.nCols)
+		for col := range rounds[row] {
+			if g.Get(row, col) == rules.Marker {
+				rounds[row][col] = NeverRemoved
+			}
+		}
+	}
+	liftable := func(row, col int) bool {
+		if g.Get(row, col) != rules.Marker {
+			return false
+		}
+		count := 0
+		for _, o := range rules.Neighborhood {
+			if r, c, ok := g.neighbor(row, col, o, rules.Wrap); ok && g.Get(r, c) == rules.Marker {
+				count++
+			}
+		}
+		return rules.Compare.holds(count, rules.Threshold)
+	}
+
+	for round := 1; ; round++ {
+		lifted := make([]Position, 0)
+		for row := 0; row < g.nRows; row++ {
+			for col := 0; col < g.nCols; col++ {
+				if !liftable(row, col) {
+					continue
+				}
+				lifted = append(lifted, Position{row: row, col: col})
+				if rules.Removal == Sequential {
+					g.RemovePaperRoll(row, col)
+				}
+			}
+		}
+		if len(lifted) == 0 {
+			return rounds
+		}
+		for _, pos := range lifted {
+			rounds[pos.row][pos.col] = round
+			g.RemovePaperRoll(pos.row, pos.col)
+		}
+	}
+}
+
+func TestPeelWithMatchesNaive(t *testing.T) {
+	rng := rand.New(rand.NewSource(33))
+	neighborhoods := []Neighborhood{
+		MooreNeighborhood(1),
+		MooreNeighborhood(2),
+		VonNeumannNeighborhood(1),
+		VonNeumannNeighborhood(2),
+		{{dr: -1, dc: 0}, {dr: 0, dc: 2}}, // asymmetric
+	}
+	comparisons := []Comparison{Less, LessOrEqual, Equal, NotEqual, GreaterOrEqual, Greater}
+
+	for round := 0; round < 400; round++ {
+		nRows, nCols := 1+rng.Intn(9), 1+rng.Intn(9)
+		data := make([][]byte, nRows)
+		for r := range data {
+			data[r] = make([]byte, nCols)
+			for c := range data[r] {
+				data[r][c] = '.'
+				if rng.Intn(10) < 6 {
+					data[r][c] = rollMarker
+				}
+			}
+		}
+		grid := Grid{nRows: nRows, nCols: nCols, data: data}
+
+		rules := DefaultRules()
+		rules.Neighborhood = neighborhoods[rng.Intn(len(neighborhoods))]
+		rules.Compare = comparisons[rng.Intn(len(comparisons))]
+		rules.Threshold = rng.Intn(6)
+		rules.Wrap = rng.Intn(2) == 0
+		if rng.Intn(2) == 0 {
+			rules.Removal = Sequential
+		}
+
+		expected := peelNaively(copyGrid(grid), rules)
+		peeled, err := grid.PeelWith(rules)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for r := 0; r < nRows; r++ {
+			for c := 0; c < nCols; c++ {
+				if peeled.Rounds[r][c] != expected[r][c] {
+					t.Fatalf("grid %d with %+v: cell (%d, %d) expected round %d, got %d",
+						round, rules, r, c, expected[r][c], peeled.Rounds[r][c])
+				}
+			}
+		}
+	}
+}
+
+func TestParseNeighborhood(t *testing.T) {
+	n, err := ParseNeighborhood("vonneumann", 1)
+	if err != nil || len(n) != 4 {
+		t.Errorf("expected 4 von Neumann neighbors, got %v (%v)", n, err)
+	}
+	n, err = ParseNeighborhood("moore", 2)
+	if err != nil || len(n) != 24 {
+		t.Errorf("expected 24 Moore neighbors, got %v (%v)", n, err)
+	}
+	n, err = ParseNeighborhood("-1,0; 0,2", 1)
+	if err != nil || len(n) != 2 || n[1] != (Offset{dr: 0, dc: 2}) {
+		t.Errorf("unexpected custom neighborhood %v (%v)", n, err)
+	}
+	if _, err := ParseNeighborhood("hexagonal", 1); err == nil {
+		t.Errorf("expected an error for an unknown neighborhood")
+	}
+
+	rules := DefaultRules()
+	rules.Compare = "<>"
+	if _, err := (&Grid{}).PeelWith(rules); err == nil {
+		t.Errorf("expected an error for an unknown comparison")
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/png"
+	"io"
+	"strings"
+)
+
+const (
+	ansiReset  = "\x1b[0m"
+	ansiRed    = "\x1b[1;31m"
+	ansiDim    = "\x1b[2m"
+	ansiYellow = "\x1b[33m"
+)
+
+// WriteANSIFrames writes one frame per removal round: the rolls lifted in that round in red,
+// the rolls still standing in yellow and the cells already cleared dimmed.
+func WriteANSIFrames(w io.Writer, peeled PeelResult) error {
+	for round := 1; round <= len(peeled.Removed); round++ {
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "round %d: %d rolls removed\n", round, peeled.Removed[round-1])
+		for _, row := range peeled.Rounds {
+			for _, r := range row {
+				switch {
+				case r == 0:
+					sb.WriteByte('.')
+				case r == round:
+					sb.WriteString(ansiRed + "@" + ansiReset)
+				case r == NeverRemoved || r > round:
+					sb.WriteString(ansiYellow + "@" + ansiReset)
+				default:
+					sb.WriteString(ansiDim + "x" + ansiReset)
+				}
+			}
+			sb.WriteByte('\n')
+		}
+		sb.WriteByte('\n')
+		if _, err := io.WriteString(w, sb.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var (
+	emptyColor   = color.RGBA{R: 0x10, G: 0x10, B: 0x10, A: 0xff}
+	clearedColor = color.RGBA{R: 0x40, G: 0x40, B: 0x40, A: 0xff}
+	standColor   = color.RGBA{R: 0xf0, G: 0xd0, B: 0x40, A: 0xff}
+	liftColor    = color.RGBA{R: 0xe0, G: 0x20, B: 0x20, A: 0xff}
+	stuckColor   = color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+)
+
+// paint fills a scale x scale block for the cell at (row, col)
+func paint(img draw.Image, row, col, scale int, c color.Color) {
+	for y := row * scale; y < (row+1)*scale; y++ {
+		for x := col * scale; x < (col+1)*scale; x++ {
+			img.Set(x, y, c)
+		}
+	}
+}
+
+// WriteGIF writes an animation with one frame per removal round, using the colors of
+// WriteANSIFrames. Every cell is drawn as a scale x scale block; delay is in 100ths of a second.
+func WriteGIF(w io.Writer, peeled PeelResult, scale int, delay int) error {
+	if scale < 1 {
+		return fmt.Errorf("scale must be at least 1, got %d", scale)
+	}
+	nRows, nCols := gridSize(peeled)
+	palette := color.Palette{emptyColor, clearedColor, standColor, liftColor}
+	bounds := image.Rect(0, 0, nCols*scale, nRows*scale)
+
+	anim := &gif.GIF{}
+	for round := 1; round <= len(peeled.Removed); round++ {
+		frame := image.NewPaletted(bounds, palette)
+		for row, cells := range peeled.Rounds {
+			for col, r := range cells {
+				switch {
+				case r == 0:
+					continue // emptyColor is index 0 already
+				case r == round:
+					paint(frame, row, col, scale, liftColor)
+				case r == NeverRemoved || r > round:
+					paint(frame, row, col, scale, standColor)
+				default:
+					paint(frame, row, col, scale, clearedColor)
+				}
+			}
+		}
+		anim.Image = append(anim.Image, frame)
+		anim.Delay = append(anim.Delay, delay)
+	}
+	if len(anim.Image) == 0 {
+		return fmt.Errorf("no roll was ever removed, nothing to animate")
+	}
+	return gif.EncodeAll(w, anim)
+}
+
+// RoundColor maps a removal round onto a blue (first round) to red (last round) gradient.
+func RoundColor(round, lastRound int) color.RGBA {
+	t := 0.0
+	if lastRound > 1 {
+		t = float64(round-1) / float64(lastRound-1)
+	}
+	return color.RGBA{R: uint8(255 * t), G: uint8(64 * (1 - t)), B: uint8(255 * (1 - t)), A: 0xff}
+}
+
+// WritePNGHeatmap writes a single image where each roll is colored by the round it was removed in,
+// see RoundColor. Rolls that are never removed are white, empty cells almost black.
+func WritePNGHeatmap(w io.Writer, peeled PeelResult, scale int) error {
+	if scale < 1 {
+		return fmt.Errorf("scale must be at least 1, got %d", scale)
+	}
+	nRows, nCols := gridSize(peeled)
+	img := image.NewRGBA(image.Rect(0, 0, nCols*scale, nRows*scale))
+	for row, cells := range peeled.Rounds {
+		for col, r := range cells {
+			switch r {
+			case 0:
+				paint(img, row, col, scale, emptyColor)
+			case NeverRemoved:
+				paint(img, row, col, scale, stuckColor)
+			default:
+				paint(img, row, col, scale, RoundColor(r, len(peeled.Removed)))
+			}
+		}
+	}
+	return png.Encode(w, img)
+}
+
+func gridSize(peeled PeelResult) (int, int) {
+	if len(peeled.Rounds) == 0 {
+		return 0, 0
+	}
+	return len(peeled.Rounds), len(peeled.Rounds[0])
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"image/gif"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func TestVisualizeExample(t *testing.T) {
+	grid := ParseInput(strings.NewReader(exampleGrid))
+	peeled := grid.Peel()
+
+	var ansi bytes.Buffer
+	if err := WriteANSIFrames(&ansi, peeled); err != nil {
+		t.Fatal(err)
+	}
+	if frames := strings.Count(ansi.String(), "rolls removed"); frames != len(peeled.Removed) {
+		t.Errorf("expected %d ANSI frames, got %d", len(peeled.Removed), frames)
+	}
+	if !strings.HasPrefix(ansi.String(), "round 1: 13 rolls removed\n") {
+		t.Errorf("unexpected first frame header %q", strings.SplitN(ansi.String(), "\n", 2)[0])
+	}
+
+	var anim bytes.Buffer
+	if err := WriteGIF(&anim, peeled, 3, 10); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := gif.DecodeAll(&anim)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded.Image) != len(peeled.Removed) {
+		t.Errorf("expected %d GIF frames, got %d", len(peeled.Removed), len(decoded.Image))
+	}
+	if b := decoded.Image[0].Bounds(); b.Dx() != 30 || b.Dy() != 30 {
+		t.Errorf("expected 30x30 frames, got %v", b)
+	}
+
+	var heatmap bytes.Buffer
+	if err := WritePNGHeatmap(&heatmap, peeled, 2); err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(&heatmap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// (0, 2) is a roll lifted in the first round, (0, 0) is empty
+	if got := img.At(4, 0); got != RoundColor(1, len(peeled.Removed)) {
+		t.Errorf("expected the first round color at (0, 2), got %v", got)
+	}
+	if got := img.At(0, 0); got != emptyColor {
+		t.Errorf("expected the empty color at (0, 0), got %v", got)
+	}
+}
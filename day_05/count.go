@@ -0,0 +1,58 @@
+package main
+
+import (
+	"math/big"
+	"math/bits"
+)
+
+// Count is a non-negative number of IDs. It is kept in a uint64 and only switches
+// to math/big once it no longer fits, which needs ranges covering more than 2^64-1 IDs.
+// Counts are values: operations return a new Count and never modify their operands.
+type Count struct {
+	small uint64
+	big   *big.Int // set when the count doesn't fit in small
+}
+
+func (c Count) Add(n uint64) Count {
+	if c.big == nil {
+		sum, carry := bits.Add64(c.small, n, 0)
+		if carry == 0 {
+			return Count{small: sum}
+		}
+		c = Count{big: new(big.Int).SetUint64(c.small)}
+	}
+	return Count{big: new(big.Int).Add(c.big, new(big.Int).SetUint64(n))}
+}
+
+// Sub returns c - o; o must not be larger than c.
+func (c Count) Sub(o Count) Count {
+	if c.big == nil && o.big == nil {
+		return Count{small: c.small - o.small}
+	}
+	diff := new(big.Int).Sub(c.Big(), o.Big())
+	if diff.IsUint64() {
+		return Count{small: diff.Uint64()}
+	}
+	return Count{big: diff}
+}
+
+// Uint64 returns the count and whether it fits in a uint64.
+func (c Count) Uint64() (uint64, bool) {
+	return c.small, c.big == nil
+}
+
+func (c Count) IsBig() bool {
+	return c.big != nil
+}
+
+// Big returns the count as a new big.Int.
+func (c Count) Big() *big.Int {
+	if c.big == nil {
+		return new(big.Int).SetUint64(c.small)
+	}
+	return new(big.Int).Set(c.big)
+}
+
+func (c Count) String() string {
+	return c.Big().String()
+}
@@ -0,0 +1,213 @@
+package main
+
+import "sort"
+
+// DataBase keeps the fresh ID ranges as given, plus an index of the same IDs as
+// sorted, non-overlapping ranges that all queries search. Ranges can be inserted
+// and deleted at any time; the index is rebuilt on the next query after a change.
+type DataBase struct {
+	ranges []IdRange
+
+	dirty bool
+	index []IdRange
+	// before[i] is the number of IDs covered by index[:i]
+	before []Count
+	// the original ranges sorted by start, and the largest end among byStart[:i+1]
+	byStart []IdRange
+	maxEnd  []uint64
+}
+
+// NewDataBase indexes the given ranges; a range given end first is stored with its bounds swapped.
+func NewDataBase(ranges []IdRange) *DataBase {
+	db := &DataBase{ranges: make([]IdRange, len(ranges))}
+	for i, r := range ranges {
+		db.ranges[i] = r.normalized()
+	}
+	db.rebuild()
+	return db
+}
+
+// merge the ranges into the canonical form: sorted ranges covering the same IDs that
+// neither overlap nor touch, so "3-5" and "6-8" become "3-8"
+// reference: https://stackoverflow.com/questions/32585990/algorithm-merge-overlapping-segments
+func mergeRanges(ranges []IdRange) []IdRange {
+	sorted := make([]IdRange, len(ranges))
+	copy(sorted, ranges)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].start < sorted[j].start
+	})
+
+	merged := make([]IdRange, 0)
+	for _, idRange := range sorted {
+		// if there are IDs between the end of the last range and the start, add a new range
+		// (written as a difference, since end+1 overflows for the last uint64)
+		if len(merged) == 0 || (idRange.start > merged[len(merged)-1].end && idRange.start-merged[len(merged)-1].end > 1) {
+			merged = append(merged, idRange)
+		} else {
+			// otherwise, merge the overlapping or adjacent ranges
+			lastRange := &merged[len(merged)-1]
+			if idRange.end > lastRange.end {
+				lastRange.end = idRange.end
+			}
+		}
+	}
+	return merged
+}
+
+func (db *DataBase) rebuild() {
+	db.index = mergeRanges(db.ranges)
+	db.before = make([]Count, len(db.index)+1)
+	for i, r := range db.index {
+		db.before[i+1] = db.before[i].Add(r.end - r.start).Add(1)
+	}
+
+	db.byStart = make([]IdRange, len(db.ranges))
+	copy(db.byStart, db.ranges)
+	sort.Slice(db.byStart, func(i, j int) bool {
+		return db.byStart[i].start < db.byStart[j].start
+	})
+	db.maxEnd = make([]uint64, len(db.byStart))
+	for i, r := range db.byStart {
+		db.maxEnd[i] = r.end
+		if i > 0 && db.maxEnd[i-1] > r.end {
+			db.maxEnd[i] = db.maxEnd[i-1]
+		}
+	}
+	db.dirty = false
+}
+
+func (db *DataBase) refresh() {
+	if db.dirty {
+		db.rebuild()
+	}
+}
+
+// Insert adds a fresh range, swapping its bounds if it is given end first.
+func (db *DataBase) Insert(r IdRange) {
+	db.ranges = append(db.ranges, r.normalized())
+	db.dirty = true
+}
+
+// Delete removes one range equal to r, as it was inserted, and reports whether there was one.
+// IDs of r that are also covered by other ranges stay fresh.
+func (db *DataBase) Delete(r IdRange) bool {
+	r = r.normalized()
+	for i, existing := range db.ranges {
+		if existing == r {
+			db.ranges = append(db.ranges[:i], db.ranges[i+1:]...)
+			db.dirty = true
+			return true
+		}
+	}
+	return false
+}
+
+// EffectiveRanges returns the fresh IDs in canonical form: sorted ranges that neither overlap nor touch.
+func (db *DataBase) EffectiveRanges() []IdRange {
+	db.refresh()
+	return append([]IdRange(nil), db.index...)
+}
+
+// Ranges returns the ranges as they were inserted.
+func (db *DataBase) Ranges() []IdRange {
+	return append([]IdRange(nil), db.ranges...)
+}
+
+// find the first index range ending at or after id
+func (db *DataBase) search(id uint64) int {
+	return sort.Search(len(db.index), func(i int) bool {
+		return db.index[i].end >= id
+	})
+}
+
+// HasID binary searches the index for the first range ending at or after id.
+func (db *DataBase) HasID(id uint64) bool {
+	db.refresh()
+	i := db.search(id)
+	return i < len(db.index) && db.index[i].Contains(id)
+}
+
+// CountFresh counts how many of the ids are fresh. The ids are sorted and swept
+// together with the index, which is cheaper than a binary search per id for big batches.
+func (db *DataBase) CountFresh(ids []uint64) int {
+	db.refresh()
+	sorted := make([]uint64, len(ids))
+	copy(sorted, ids)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	fresh := 0
+	i := 0
+	for _, id := range sorted {
+		for i < len(db.index) && db.index[i].end < id {
+			i++
+		}
+		if i == len(db.index) {
+			break
+		}
+		if db.index[i].Contains(id) {
+			fresh++
+		}
+	}
+	return fresh
+}
+
+// CoveringRanges returns the inserted ranges that contain id, ordered by start.
+func (db *DataBase) CoveringRanges(id uint64) []IdRange {
+	db.refresh()
+	// ranges starting after id can't contain it
+	last := sort.Search(len(db.byStart), func(i int) bool {
+		return db.byStart[i].start > id
+	}) - 1
+
+	covering := make([]IdRange, 0)
+	// once no earlier range reaches id we can stop
+	for j := last; j >= 0 && db.maxEnd[j] >= id; j-- {
+		if db.byStart[j].end >= id {
+			covering = append(covering, db.byStart[j])
+		}
+	}
+	for i, j := 0, len(covering)-1; i < j; i, j = i+1, j-1 {
+		covering[i], covering[j] = covering[j], covering[i]
+	}
+	return covering
+}
+
+// countUpTo returns the number of fresh IDs that are <= id
+func (db *DataBase) countUpTo(id uint64) Count {
+	i := db.search(id)
+	count := db.before[i]
+	if i < len(db.index) && db.index[i].start <= id {
+		count = count.Add(id - db.index[i].start).Add(1)
+	}
+	return count
+}
+
+// FreshInWindow counts the fresh IDs in [low, high].
+func (db *DataBase) FreshInWindow(low, high uint64) Count {
+	db.refresh()
+	if low > high {
+		return Count{}
+	}
+	count := db.countUpTo(high)
+	if low > 0 {
+		count = count.Sub(db.countUpTo(low - 1))
+	}
+	return count
+}
+
+// TotalFresh counts all fresh IDs.
+func (db *DataBase) TotalFresh() Count {
+	db.refresh()
+	return db.before[len(db.index)]
+}
+
+// SpoiledGaps lists the IDs between the first and the last fresh range that are not fresh.
+func (db *DataBase) SpoiledGaps() []IdRange {
+	db.refresh()
+	gaps := make([]IdRange, 0)
+	// the index is canonical, so there is at least one ID between two of its ranges
+	for i := 1; i < len(db.index); i++ {
+		gaps = append(gaps, IdRange{start: db.index[i-1].end + 1, end: db.index[i].start - 1})
+	}
+	return gaps
+}
@@ -1,20 +1,25 @@
 package main
 
 import (
+	"math"
+	"math/big"
+	"math/rand"
+	"reflect"
+	"strings"
 	"testing"
 )
 
 func TestDatabaseHasID(t *testing.T) {
 
-	db := DataBase{
-		IdRange{start: 3, end: 5},
-		IdRange{start: 10, end: 14},
-		IdRange{start: 16, end: 20},
-		IdRange{start: 12, end: 18},
-	}
+	db := NewDataBase([]IdRange{
+		{start: 3, end: 5},
+		{start: 10, end: 14},
+		{start: 16, end: 20},
+		{start: 12, end: 18},
+	})
 
 	tests := []struct {
-		id       int
+		id       uint64
 		expected bool
 	}{
 		{id: 1, expected: false},
@@ -25,11 +30,327 @@ func TestDatabaseHasID(t *testing.T) {
 		{id: 32, expected: false},
 	}
 
+	ids := make([]uint64, 0)
+	fresh := 0
 	for _, test := range tests {
 		result := db.HasID(test.id)
 		if result != test.expected {
 			t.Errorf("HasID(%d) = %v; want %v", test.id, result, test.expected)
 		}
+		ids = append(ids, test.id)
+		if test.expected {
+			fresh++
+		}
+	}
+
+	if count := db.CountFresh(ids); count != fresh {
+		t.Errorf("CountFresh(%v) = %d; want %d", ids, count, fresh)
+	}
+
+}
+
+func TestIndexMatchesLinearScan(t *testing.T) {
+	rng := rand.New(rand.NewSource(36))
+	for round := 0; round < 100; round++ {
+		ranges := make([]IdRange, rng.Intn(20))
+		for i := range ranges {
+			start := uint64(rng.Intn(200))
+			ranges[i] = IdRange{start: start, end: start + uint64(rng.Intn(30))}
+		}
+		db := NewDataBase(ranges)
+
+		ids := make([]uint64, 0)
+		fresh := 0
+		for id := uint64(0); id < 240; id++ {
+			expected := false
+			for _, r := range ranges {
+				expected = expected || r.Contains(id)
+			}
+			if db.HasID(id) != expected {
+				t.Fatalf("ranges %v: HasID(%d) = %v; want %v", ranges, id, !expected, expected)
+			}
+			ids = append(ids, id)
+			if expected {
+				fresh++
+			}
+		}
+		rng.Shuffle(len(ids), func(i, j int) { ids[i], ids[j] = ids[j], ids[i] })
+		if count := db.CountFresh(ids); count != fresh {
+			t.Fatalf("ranges %v: CountFresh = %d; want %d", ranges, count, fresh)
+		}
+	}
+}
+
+const benchSize = 1_000_000
+
+func benchData() ([]IdRange, []uint64) {
+	rng := rand.New(rand.NewSource(1))
+	ranges := make([]IdRange, benchSize)
+	for i := range ranges {
+		start := rng.Uint64()
+		ranges[i] = IdRange{start: start, end: start + min(uint64(rng.Intn(1<<20)), math.MaxUint64-start)}
+	}
+	ids := make([]uint64, benchSize)
+	for i := range ids {
+		ids[i] = rng.Uint64()
+	}
+	return ranges, ids
+}
+
+func BenchmarkNewDataBase(b *testing.B) {
+	ranges, _ := benchData()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		NewDataBase(ranges)
+	}
+}
+
+func BenchmarkHasID(b *testing.B) {
+	ranges, ids := benchData()
+	db := NewDataBase(ranges)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, id := range ids {
+			db.HasID(id)
+		}
+	}
+}
+
+func BenchmarkCountFresh(b *testing.B) {
+	ranges, ids := benchData()
+	db := NewDataBase(ranges)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		db.CountFresh(ids)
+	}
+}
+
+func TestMutableDataBase(t *testing.T) {
+	rng := rand.New(rand.NewSource(37))
+	db := NewDataBase(nil)
+	inserted := make([]IdRange, 0)
+
+	for step := 0; step < 300; step++ {
+		if len(inserted) > 0 && rng.Intn(3) == 0 {
+			i := rng.Intn(len(inserted))
+			if !db.Delete(inserted[i]) {
+				t.Fatalf("Delete(%v) found nothing", inserted[i])
+			}
+			inserted = append(inserted[:i], inserted[i+1:]...)
+		} else {
+			start := uint64(1 + rng.Intn(100))
+			r := IdRange{start: start, end: start + uint64(rng.Intn(15))}
+			db.Insert(r)
+			inserted = append(inserted, r)
+		}
+
+		fresh := func(id uint64) bool {
+			for _, r := range inserted {
+				if r.Contains(id) {
+					return true
+				}
+			}
+			return false
+		}
+
+		id := uint64(rng.Intn(120))
+		covering := db.CoveringRanges(id)
+		expectedCovering := 0
+		for _, r := range inserted {
+			if r.Contains(id) {
+				expectedCovering++
+			}
+		}
+		if len(covering) != expectedCovering {
+			t.Fatalf("step %d: CoveringRanges(%d) = %v, expected %d ranges", step, id, covering, expectedCovering)
+		}
+		for _, r := range covering {
+			if !r.Contains(id) {
+				t.Fatalf("step %d: CoveringRanges(%d) returned %v", step, id, r)
+			}
+		}
+
+		low := uint64(rng.Intn(120))
+		high := low + uint64(rng.Intn(60))
+		expectedWindow := uint64(0)
+		for id := low; id <= high; id++ {
+			if fresh(id) {
+				expectedWindow++
+			}
+		}
+		if got, ok := db.FreshInWindow(low, high).Uint64(); !ok || got != expectedWindow {
+			t.Fatalf("step %d: FreshInWindow(%d, %d) = %d; want %d", step, low, high, got, expectedWindow)
+		}
+
+		total := uint64(0)
+		for id := uint64(0); id < 120; id++ {
+			if fresh(id) {
+				total++
+			}
+		}
+		if got, ok := db.TotalFresh().Uint64(); !ok || got != total {
+			t.Fatalf("step %d: TotalFresh() = %d; want %d", step, db.TotalFresh(), total)
+		}
+
+		for _, gap := range db.SpoiledGaps() {
+			if gap.start > gap.end || fresh(gap.start) || fresh(gap.end) || !fresh(gap.start-1) || !fresh(gap.end+1) {
+				t.Fatalf("step %d: invalid spoiled gap %v", step, gap)
+			}
+		}
+	}
+
+	if db.Delete(IdRange{start: 1000, end: 1005}) {
+		t.Errorf("Delete of a range that was never inserted should report false")
+	}
+}
+
+func TestSpoiledGaps(t *testing.T) {
+	db := NewDataBase([]IdRange{
+		{start: 3, end: 5},
+		{start: 10, end: 14},
+		{start: 16, end: 20},
+		{start: 12, end: 18},
+	})
+	gaps := db.SpoiledGaps()
+	if len(gaps) != 1 || gaps[0] != (IdRange{start: 6, end: 9}) {
+		t.Errorf("SpoiledGaps() = %v; want [{6 9}]", gaps)
+	}
+	if covering := db.CoveringRanges(13); len(covering) != 2 {
+		t.Errorf("CoveringRanges(13) = %v; want 2 ranges", covering)
+	}
+}
+
+func TestExtremeBoundaries(t *testing.T) {
+	const maxID = math.MaxUint64
+
+	db := NewDataBase([]IdRange{{start: maxID - 9, end: maxID}})
+	if !db.HasID(maxID) || db.HasID(maxID-10) {
+		t.Errorf("HasID is wrong around the top of the ID space")
+	}
+	if got, ok := db.TotalFresh().Uint64(); !ok || got != 10 {
+		t.Errorf("TotalFresh() = %v; want 10", db.TotalFresh())
+	}
+
+	// all of uint64: 2^64 IDs, one more than a uint64 can hold
+	db = NewDataBase([]IdRange{{start: 0, end: maxID}})
+	total := db.TotalFresh()
+	expected := new(big.Int).Lsh(big.NewInt(1), 64)
+	if !total.IsBig() || total.Big().Cmp(expected) != 0 {
+		t.Errorf("TotalFresh() = %v; want %v", total, expected)
+	}
+	if window := db.FreshInWindow(0, maxID); window.Big().Cmp(expected) != 0 {
+		t.Errorf("FreshInWindow(0, max) = %v; want %v", window, expected)
+	}
+	if window, ok := db.FreshInWindow(1, maxID).Uint64(); !ok || window != maxID {
+		t.Errorf("FreshInWindow(1, max) = %v; want %d", window, uint64(maxID))
+	}
+	if window, ok := db.FreshInWindow(maxID, maxID).Uint64(); !ok || window != 1 {
+		t.Errorf("FreshInWindow(max, max) = %v; want 1", window)
+	}
+
+	// overlapping ranges covering the whole space still count every ID once
+	db = NewDataBase([]IdRange{{start: 0, end: maxID - 1}, {start: 1, end: maxID}, {start: 5, end: 7}})
+	if db.TotalFresh().Big().Cmp(expected) != 0 {
+		t.Errorf("TotalFresh() = %v; want %v", db.TotalFresh(), expected)
+	}
+	if gaps := db.SpoiledGaps(); len(gaps) != 0 {
+		t.Errorf("SpoiledGaps() = %v; want none", gaps)
+	}
+
+	// two disjoint halves: the sum crosses 2^64 only at the very end
+	db = NewDataBase([]IdRange{{start: 0, end: 1 << 63}, {start: 1<<63 + 2, end: maxID}})
+	expected = new(big.Int).Sub(expected, big.NewInt(1))
+	if got, ok := db.TotalFresh().Uint64(); !ok || got != maxID {
+		t.Errorf("TotalFresh() = %v; want %v", db.TotalFresh(), expected)
+	}
+	if gaps := db.SpoiledGaps(); len(gaps) != 1 || gaps[0] != (IdRange{start: 1<<63 + 1, end: 1<<63 + 1}) {
+		t.Errorf("SpoiledGaps() = %v", gaps)
+	}
+}
+
+func TestCount(t *testing.T) {
+	c := Count{}.Add(math.MaxUint64)
+	if c.IsBig() {
+		t.Errorf("MaxUint64 should fit in a uint64")
+	}
+	c = c.Add(2)
+	if !c.IsBig() || c.String() != "18446744073709551617" {
+		t.Errorf("expected 2^64+1, got %v", c)
+	}
+	c = c.Sub(Count{}.Add(5))
+	if v, ok := c.Uint64(); !ok || v != math.MaxUint64-3 {
+		t.Errorf("expected the count to fit in a uint64 again, got %v", c)
+	}
+}
+
+func TestEffectiveRangesAreCanonical(t *testing.T) {
+	db := NewDataBase([]IdRange{
+		{start: 6, end: 8},
+		{start: 3, end: 5},
+		{start: 10, end: 14},
+		{start: 15, end: 15},
+		{start: 20, end: 25},
+		{start: 22, end: 23},
+	})
+
+	expected := []IdRange{{start: 3, end: 8}, {start: 10, end: 15}, {start: 20, end: 25}}
+	got := db.EffectiveRanges()
+	if len(got) != len(expected) {
+		t.Fatalf("EffectiveRanges() = %v; want %v", got, expected)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("EffectiveRanges() = %v; want %v", got, expected)
+		}
+	}
+
+	if gaps := db.SpoiledGaps(); len(gaps) != 2 || gaps[0].String() != "9-9" || gaps[1].String() != "16-19" {
+		t.Errorf("SpoiledGaps() = %v; want [9-9 16-19]", gaps)
+	}
+
+	// adjacent at the very top of the ID space
+	db = NewDataBase([]IdRange{{start: math.MaxUint64, end: math.MaxUint64}, {start: 0, end: math.MaxUint64 - 1}})
+	if got := db.EffectiveRanges(); len(got) != 1 || got[0] != (IdRange{start: 0, end: math.MaxUint64}) {
+		t.Errorf("EffectiveRanges() = %v; want one range covering everything", got)
+	}
+}
+
+func TestParseIDRangesErrors(t *testing.T) {
+	ranges, err := ParseIDRanges("3-5\n10-14\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []IdRange{{start: 3, end: 5}, {start: 10, end: 14}}; !reflect.DeepEqual(ranges, expected) {
+		t.Errorf("ParseIDRanges() = %v; want %v", ranges, expected)
+	}
+
+	for _, input := range []string{"3-5\n14-10", "3-5\nabc", "3-5\n7", "3-5x", "-5", "3-18446744073709551616"} {
+		if _, err := ParseIDRanges(input); err == nil {
+			t.Errorf("ParseIDRanges(%q) should fail", input)
+		}
+	}
+
+	if _, _, err := ParseInput(strings.NewReader("3-5\n\n1\nx\n")); err == nil {
+		t.Error("ParseInput() should fail on a malformed ID")
 	}
+	if _, _, err := ParseInput(strings.NewReader("3-5\n")); err == nil {
+		t.Error("ParseInput() should fail without the list of IDs")
+	}
+}
 
+func TestReversedRanges(t *testing.T) {
+	db := NewDataBase([]IdRange{{start: 14, end: 10}})
+	db.Insert(IdRange{start: 5, end: 3})
+	if total := db.TotalFresh(); total.String() != "8" {
+		t.Errorf("TotalFresh() = %s; want 8", total)
+	}
+	if expected := []IdRange{{start: 3, end: 5}, {start: 10, end: 14}}; !reflect.DeepEqual(db.EffectiveRanges(), expected) {
+		t.Errorf("EffectiveRanges() = %v; want %v", db.EffectiveRanges(), expected)
+	}
+	if !db.HasID(12) || db.HasID(7) {
+		t.Error("HasID() got the reversed ranges wrong")
+	}
+	if !db.Delete(IdRange{start: 5, end: 3}) {
+		t.Error("Delete() should find the range inserted end first")
+	}
 }
@@ -2,126 +2,153 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"io"
 	"os"
-	"sort"
+	"strconv"
 	"strings"
 )
 
+// IdRange is an inclusive range of ingredient IDs; any uint64 is a valid ID.
 type IdRange struct {
-	start int
-	end   int
+	start uint64
+	end   uint64
 }
 
-func (r IdRange) Contains(id int) bool {
+func (r IdRange) Contains(id uint64) bool {
 	return id >= r.start && id <= r.end
 }
 
-type DataBase []IdRange
+// String formats the range the way it is written in the input, e.g. "3-5".
+func (r IdRange) String() string {
+	return fmt.Sprintf("%d-%d", r.start, r.end)
+}
 
-func (db DataBase) HasID(id int) bool {
-	for _, r := range db {
-		if r.Contains(id) {
-			return true
-		}
+// normalized returns the range with its bounds in increasing order.
+func (r IdRange) normalized() IdRange {
+	if r.start > r.end {
+		r.start, r.end = r.end, r.start
 	}
-	return false
+	return r
 }
 
-func ParseIDRanges(input string) []IdRange {
+// ParseIDRanges reads one "start-end" range per line. A range whose start is after its
+// end is an error: in the puzzle input it can only be a typo or a damaged line, and
+// reading it silently would hide that. This is stricter than the DataBase API, which
+// swaps the bounds of a reversed IdRange, since a range built in code has no written
+// order that could be wrong.
+func ParseIDRanges(input string) ([]IdRange, error) {
 	idRanges := make([]IdRange, 0)
 	lines := strings.Split(strings.TrimSpace(input), "\n")
-	for _, line := range lines {
-		var start, end int
-		fmt.Sscanf(line, "%d-%d", &start, &end)
+	for n, line := range lines {
+		startText, endText, ok := strings.Cut(strings.TrimSpace(line), "-")
+		if !ok {
+			return nil, fmt.Errorf("line %d: %q is not a start-end range", n+1, line)
+		}
+		start, err := strconv.ParseUint(startText, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n+1, err)
+		}
+		end, err := strconv.ParseUint(endText, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n+1, err)
+		}
+		if start > end {
+			return nil, fmt.Errorf("line %d: range %q starts after it ends", n+1, line)
+		}
 		idRanges = append(idRanges, IdRange{start: start, end: end})
 	}
 
-	return idRanges
+	return idRanges, nil
 }
 
-func ParseIntegerList(input string) []int {
+func ParseIntegerList(input string) ([]uint64, error) {
 
-	intList := make([]int, 0)
+	intList := make([]uint64, 0)
 	lines := strings.Split(strings.TrimSpace(input), "\n")
-	for _, line := range lines {
-		var value int
-		fmt.Sscanf(line, "%d", &value)
+	for n, line := range lines {
+		value, err := strconv.ParseUint(strings.TrimSpace(line), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n+1, err)
+		}
 		intList = append(intList, value)
 	}
 
-	return intList
+	return intList, nil
 }
 
-func ParseInput(r io.Reader) ([]IdRange, []int) {
+func ParseInput(r io.Reader) ([]IdRange, []uint64, error) {
 	input, err := io.ReadAll(r)
 	if err != nil {
-		panic(err)
+		return nil, nil, err
 	}
 
 	// split the input by a blank line
 	sections := strings.Split(string(input), "\n\n")
+	if len(sections) < 2 {
+		return nil, nil, fmt.Errorf("expected the ranges and the available IDs separated by a blank line")
+	}
 
-	idRanges := ParseIDRanges(sections[0])
-	availablIngredients := ParseIntegerList(sections[1])
+	idRanges, err := ParseIDRanges(sections[0])
+	if err != nil {
+		return nil, nil, fmt.Errorf("fresh ranges: %w", err)
+	}
+	availablIngredients, err := ParseIntegerList(sections[1])
+	if err != nil {
+		return nil, nil, fmt.Errorf("available IDs: %w", err)
+	}
 
-	return idRanges, availablIngredients
+	return idRanges, availablIngredients, nil
 
 }
 
-func Part1() {
-	idRanges, availableIngredients := ParseInput(os.Stdin)
+func Part1() error {
+	idRanges, availableIngredients, err := ParseInput(os.Stdin)
+	if err != nil {
+		return err
+	}
 
-	dataBase := DataBase(idRanges)
+	dataBase := NewDataBase(idRanges)
 
-	freshIngredientCount := 0
-	for _, ingredientID := range availableIngredients {
-		if dataBase.HasID(ingredientID) {
-			freshIngredientCount++
-		}
-	}
+	freshIngredientCount := dataBase.CountFresh(availableIngredients)
 
 	fmt.Println("Number of fresh ingredients available:", freshIngredientCount)
+	return nil
 }
 
-func Part2() {
+// Part2 counts the fresh IDs; with printRanges it also lists the canonical fresh ranges.
+func Part2(printRanges bool) error {
 	// find how many total effective ingredient IDs are available
-	idRanges, _ := ParseInput(os.Stdin)
+	idRanges, _, err := ParseInput(os.Stdin)
+	if err != nil {
+		return err
+	}
 
 	// we need to essentially merge the idRanges to find the total coverage
-	// reference: https://stackoverflow.com/questions/32585990/algorithm-merge-overlapping-segments
-
-	sort.Slice(idRanges, func(i, j int) bool {
-		return idRanges[i].start < idRanges[j].start
-	})
-
-	effectiveRanges := make([]IdRange, 0)
-	for _, idRange := range idRanges {
-		// if the start bigger than the end of the last range, add a new range
-		if len(effectiveRanges) == 0 || idRange.start > effectiveRanges[len(effectiveRanges)-1].end {
-			effectiveRanges = append(effectiveRanges, idRange)
-		} else {
-			// otherwise, merge the ranges
-			lastRange := &effectiveRanges[len(effectiveRanges)-1]
-			if idRange.end > lastRange.end {
-				lastRange.end = idRange.end
-			}
-		}
-	}
+	dataBase := NewDataBase(idRanges)
+	totalEffectiveIDs := dataBase.TotalFresh()
 
-	totalEffectiveIDs := 0
-	for _, r := range effectiveRanges {
-		totalEffectiveIDs += (r.end - r.start + 1)
+	if printRanges {
+		for _, r := range dataBase.EffectiveRanges() {
+			fmt.Println(r)
+		}
 	}
 
 	fmt.Println("Total effective ingredient IDs available:", totalEffectiveIDs)
+	return nil
 }
 
 func main() {
+	printRanges := flag.Bool("ranges", false, "print the canonical list of fresh ID ranges")
+	flag.Parse()
+
 	fmt.Println("Day 5: Cafeteria")
 
 	// Part1()
-	Part2()
+	if err := Part2(*printRanges); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 
 }
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// operandStrings returns the operands in reading order as decimal strings
+func (op Operation) operandStrings() []string {
+	if op.bigOperands != nil {
+		out := make([]string, len(op.bigOperands))
+		for i, operand := range op.bigOperands {
+			out[i] = operand.String()
+		}
+		return out
+	}
+	out := make([]string, len(op.operands))
+	for i, operand := range op.operands {
+		out[i] = strconv.Itoa(operand)
+	}
+	return out
+}
+
+// FormatProblem writes the operation on one line, e.g. "123 * 45 * 6".
+// A problem with a single operand keeps its operator at the end: "94 /".
+func FormatProblem(op Operation) string {
+	operands := op.operandStrings()
+	if len(operands) == 1 {
+		return operands[0] + " " + op.operator
+	}
+	return strings.Join(operands, " "+op.operator+" ")
+}
+
+// FormatProblems writes one problem per line.
+func FormatProblems(operations []Operation) string {
+	var sb strings.Builder
+	for _, op := range operations {
+		sb.WriteString(FormatProblem(op))
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+// ParseProblems reads problems written one per line as by FormatProblem. Operands and
+// operators are separated by spaces, a line can only use one operator and that operator
+// must be in the registry, so that "1 2 3" isn't read as 1 and 3 combined with "2".
+func ParseProblems(input string, reg Registry) ([]Operation, error) {
+	operations := make([]Operation, 0)
+	for lineNo, line := range strings.Split(input, "\n") {
+		tokens := strings.Fields(line)
+		if len(tokens) == 0 {
+			continue
+		}
+
+		op := Operation{}
+		for i, token := range tokens {
+			if i%2 == 1 {
+				if op.operator != "" && token != op.operator {
+					return nil, fmt.Errorf("line %d mixes operators %q and %q", lineNo+1, op.operator, token)
+				}
+				if _, ok := reg[token]; !ok {
+					return nil, fmt.Errorf("unknown operator %q on line %d", token, lineNo+1)
+				}
+				op.operator = token
+				continue
+			}
+			small, large, ok := parseOperand(token)
+			if !ok {
+				return nil, fmt.Errorf("invalid operand %q on line %d", token, lineNo+1)
+			}
+			op.appendOperand(small, large)
+		}
+
+		switch {
+		case op.operator == "":
+			return nil, fmt.Errorf("line %d has no operator", lineNo+1)
+		case len(tokens)%2 == 0 && len(tokens) != 2:
+			// "a op b op" - only a single operand may be followed by its operator
+			return nil, fmt.Errorf("line %d ends with an operator", lineNo+1)
+		}
+		operations = append(operations, op)
+	}
+	return operations, nil
+}
+
+// RenderRows lays the problems out left to right for the row-wise reading: every operand
+// on its own line, right aligned within its problem, and the operators on the last line.
+func RenderRows(operations []Operation) string {
+	height := 0
+	for _, op := range operations {
+		height = max(height, len(op.operandStrings()))
+	}
+
+	lines := make([]strings.Builder, height+1)
+	for p, op := range operations {
+		operands := op.operandStrings()
+		width := len(op.operator)
+		for _, operand := range operands {
+			width = max(width, len(operand))
+		}
+		for row := 0; row < height; row++ {
+			if p > 0 {
+				lines[row].WriteByte(' ')
+			}
+			text := ""
+			if row < len(operands) {
+				text = operands[row]
+			}
+			lines[row].WriteString(strings.Repeat(" ", width-len(text)) + text)
+		}
+		if p > 0 {
+			lines[height].WriteByte(' ')
+		}
+		lines[height].WriteString(op.operator + strings.Repeat(" ", width-len(op.operator)))
+	}
+	return joinLines(lines)
+}
+
+// RenderColumns lays the problems out for the cephalopod reading that ColumnProblems does:
+// the first problem is the rightmost one, and within a problem the first operand is the
+// rightmost column, its digits written top to bottom. The operator goes under the leftmost column.
+func RenderColumns(operations []Operation) string {
+	height := 0
+	for _, op := range operations {
+		for _, operand := range op.operandStrings() {
+			height = max(height, len(operand))
+		}
+	}
+
+	lines := make([]strings.Builder, height+1)
+	for p := len(operations) - 1; p >= 0; p-- {
+		op := operations[p]
+		operands := op.operandStrings()
+		width := max(len(operands), len(op.operator))
+
+		if p < len(operations)-1 {
+			for row := range lines {
+				lines[row].WriteByte(' ')
+			}
+		}
+		// column c of the problem, counted from the left, holds operand width-1-c
+		for c := 0; c < width; c++ {
+			i := width - 1 - c
+			for row := 0; row < height; row++ {
+				digit := byte(' ')
+				if i < len(operands) && row < len(operands[i]) {
+					digit = operands[i][row]
+				}
+				lines[row].WriteByte(digit)
+			}
+		}
+		lines[height].WriteString(op.operator + strings.Repeat(" ", width-len(op.operator)))
+	}
+	return joinLines(lines)
+}
+
+func joinLines(lines []strings.Builder) string {
+	var sb strings.Builder
+	for i := range lines {
+		sb.WriteString(strings.TrimRight(lines[i].String(), " "))
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
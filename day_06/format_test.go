@@ -0,0 +1,120 @@
+package main
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+// problemsOf strips the worksheet column, which depends on the layout, for comparisons
+func problemsOf(ops []Operation) []string {
+	out := make([]string, len(ops))
+	for i, op := range ops {
+		out[i] = FormatProblem(op)
+	}
+	return out
+}
+
+func roundTripProblems() []Operation {
+	large, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	return []Operation{
+		{operator: "*", operands: []int{123, 45, 6}},
+		{operator: "/", operands: []int{94}},
+		{operator: "max", operands: []int{7, 1234, 56}},
+		{operator: "+", bigOperands: []*big.Int{large, big.NewInt(5)}},
+	}
+}
+
+func TestFormatProblems(t *testing.T) {
+	ops := roundTripProblems()
+	expected := "123 * 45 * 6\n" +
+		"94 /\n" +
+		"7 max 1234 max 56\n" +
+		"123456789012345678901234567890 + 5\n"
+	if got := FormatProblems(ops); got != expected {
+		t.Errorf("FormatProblems() = %q; want %q", got, expected)
+	}
+
+	parsed, err := ParseProblems(expected, NewRegistry())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(parsed, ops) {
+		t.Errorf("ParseProblems() = %v; want %v", parsed, ops)
+	}
+}
+
+func TestParseProblemsErrors(t *testing.T) {
+	for _, input := range []string{
+		"1 + 2 * 3",
+		"1 + x",
+		"42",
+		"1 + 2 +",
+		"1 2 3",
+		"4 % 2",
+	} {
+		if _, err := ParseProblems(input, NewRegistry()); err == nil {
+			t.Errorf("ParseProblems(%q) should fail", input)
+		}
+	}
+}
+
+func TestRenderExampleWorksheet(t *testing.T) {
+	ws, err := NewWorksheet(exampleWorksheet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := ws.RowProblems()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedRows := "123 328  51  64\n" +
+		" 45  64 387  23\n" +
+		"  6  98 215 314\n" +
+		"*   +   *   +\n"
+	if got := RenderRows(rows); got != expectedRows {
+		t.Errorf("RenderRows() = %q; want %q", got, expectedRows)
+	}
+
+	columns, err := ws.ColumnProblems()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedColumns := "123 328 351 644\n" +
+		" 45 64  287 23\n" +
+		"  6 98   15 31\n" +
+		"*   +   *   +\n"
+	if got := RenderColumns(columns); got != expectedColumns {
+		t.Errorf("RenderColumns() = %q; want %q", got, expectedColumns)
+	}
+}
+
+func TestRenderRoundTrip(t *testing.T) {
+	ops := roundTripProblems()
+	want := problemsOf(ops)
+
+	ws, err := NewWorksheet(RenderRows(ops))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := ws.RowProblems()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := problemsOf(rows); !reflect.DeepEqual(got, want) {
+		t.Errorf("RowProblems(RenderRows()) = %v; want %v", got, want)
+	}
+
+	ws, err = NewWorksheet(RenderColumns(ops))
+	if err != nil {
+		t.Fatal(err)
+	}
+	columns, err := ws.ColumnProblems()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := problemsOf(columns); !reflect.DeepEqual(got, want) {
+		t.Errorf("ColumnProblems(RenderColumns()) = %v; want %v", got, want)
+	}
+}
@@ -1,160 +1,226 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
 	"io"
+	"math/big"
 	"os"
-	"strconv"
-	"strings"
 )
 
-func ParseInput() (numberRows [][]int, operatorRow []string) {
-	input, err := io.ReadAll(os.Stdin)
-	if err != nil {
-		panic(err)
-	}
-
-	lines := strings.Split(string(input), "\n")
-
-	numberRows = make([][]int, 0)
-	operatorRow = make([]string, 0)
-
-	for _, line := range lines {
-		tokens := strings.Fields(line)
-		_, err := strconv.Atoi(tokens[0])
-		if err != nil {
-			// this is an operator row
-			operatorRow = tokens
-		} else {
-			// this is a number row
-			numberRow := make([]int, 0)
-			for _, token := range tokens {
-				num, err := strconv.Atoi(token)
-				if err != nil {
-					panic(err)
-				}
-				numberRow = append(numberRow, num)
-			}
-			numberRows = append(numberRows, numberRow)
-		}
-	}
-
-	// fmt.Println("Number Rows:", numberRows)
-	// fmt.Println("Operator Row:", operatorRow)
-
-	return numberRows, operatorRow
-}
-
 type Operation struct {
 	operator string
 	operands []int
+	// all operands as big numbers, only set when one of them doesn't fit in an int
+	bigOperands []*big.Int
+	column      int // 1-based worksheet column where the problem starts, for error messages
 }
 
-func (op Operation) Apply() int {
-	switch op.operator {
-	case "*":
-		result := 1
-		for _, operand := range op.operands {
-			result *= operand
-		}
-		return result
-
-	case "+":
-		result := 0
+// appendOperand adds an operand that is either small or, when it doesn't fit in an int, large
+func (op *Operation) appendOperand(small int, large *big.Int) {
+	if large == nil && op.bigOperands == nil {
+		op.operands = append(op.operands, small)
+		return
+	}
+	if op.bigOperands == nil {
+		op.bigOperands = make([]*big.Int, 0, len(op.operands)+1)
 		for _, operand := range op.operands {
-			result += operand
+			op.bigOperands = append(op.bigOperands, big.NewInt(int64(operand)))
 		}
-		return result
 	}
-	return -1
+	if large == nil {
+		large = big.NewInt(int64(small))
+	}
+	op.bigOperands = append(op.bigOperands, large)
 }
 
-func Part1() {
-	numberRows, operatorRow := ParseInput()
+// Apply evaluates the operation with the built-in operators.
+func (op Operation) Apply() (int, error) {
+	return op.ApplyWith(builtinOperators)
+}
 
-	operations := make([]Operation, len(operatorRow))
+// ApplyWith is Evaluate for results that must fit in an int; it returns ErrOverflow otherwise.
+func (op Operation) ApplyWith(operators Registry) (int, error) {
+	value, err := op.Evaluate(operators, false)
+	if err != nil {
+		return 0, err
+	}
+	result, ok := value.Int()
+	if !ok {
+		return 0, fmt.Errorf("problem at column %d: %w", op.column, ErrOverflow)
+	}
+	return result, nil
+}
 
-	// loop through columns
-	for col := 0; col < len(operatorRow); col++ {
-		operations[col] = Operation{
-			operator: operatorRow[col],
-			operands: make([]int, len(numberRows))}
+// Evaluate folds the operands from left to right, in the order they were read, so that
+// non-commutative operators work as written: "-" on 10, 3, 2 is (10 - 3) - 2.
+//
+// The fold runs on ints with overflow checks; if it overflows, or an operand is too large
+// for an int, or forceBig is set, the problem is computed with math/big instead.
+func (op Operation) Evaluate(operators Registry, forceBig bool) (Value, error) {
+	f, ok := operators[op.operator]
+	if !ok {
+		return Value{}, fmt.Errorf("unknown operator %q in problem at column %d", op.operator, op.column)
+	}
+	if len(op.operands) == 0 && len(op.bigOperands) == 0 {
+		return Value{}, fmt.Errorf("operator %q in problem at column %d has no operands", op.operator, op.column)
+	}
 
-		for row := 0; row < len(numberRows); row++ {
-			operations[col].operands[row] = numberRows[row][col]
+	if !forceBig && op.bigOperands == nil && f.Int != nil {
+		result := op.operands[0]
+		var err error
+		for _, operand := range op.operands[1:] {
+			result, err = f.Int(result, operand)
+			if err != nil {
+				break
+			}
+		}
+		if err == nil {
+			return IntValue(result), nil
+		}
+		if !errors.Is(err, ErrOverflow) {
+			return Value{}, fmt.Errorf("problem at column %d: %w", op.column, err)
 		}
 	}
 
-	totalSum := 0
-	for _, op := range operations {
-		// fmt.Println("Operation:", op.operator, "Operands:", op.operands, "Result:", op.Apply())
-		totalSum += op.Apply()
+	if f.Big == nil {
+		return Value{}, fmt.Errorf("problem at column %d: %w and operator %q has no big number version", op.column, ErrOverflow, op.operator)
+	}
+	operands := op.bigOperands
+	if operands == nil {
+		operands = make([]*big.Int, len(op.operands))
+		for i, operand := range op.operands {
+			operands[i] = big.NewInt(int64(operand))
+		}
+	}
+	result := operands[0]
+	for _, operand := range operands[1:] {
+		var err error
+		result, err = f.Big(result, operand)
+		if err != nil {
+			return Value{}, fmt.Errorf("problem at column %d: %w", op.column, err)
+		}
 	}
+	return BigValue(result), nil
+}
 
-	fmt.Println("Total Sum of all operations:", totalSum)
+// sumOperations adds up all problems, switching to math/big for the total when it overflows
+// and for everything when forceBig is set.
+func sumOperations(operations []Operation, operators Registry, forceBig bool) (Value, error) {
+	totalSum := IntValue(0)
+	if forceBig {
+		totalSum = BigValue(new(big.Int))
+	}
+	for _, op := range operations {
+		result, err := op.Evaluate(operators, forceBig)
+		if err != nil {
+			return Value{}, err
+		}
+		totalSum = totalSum.Add(result)
+	}
+	return totalSum, nil
 }
 
-func Part2() {
-	input, err := io.ReadAll(os.Stdin)
+// Solve reads the worksheet from stdin in the cephalopod (column) or the row-wise order
+// and prints the grand total.
+func Solve(columns bool, operators Registry, forceBig bool) error {
+	worksheet, err := ParseWorksheet(os.Stdin)
 	if err != nil {
-		panic(err)
+		return err
 	}
 
-	lines := strings.Split(string(input), "\n")
-
-	// conver to columns of bytes
+	var operations []Operation
+	if columns {
+		// scan from right to left, parse the operand and operators
+		operations, err = worksheet.ColumnProblems()
+	} else {
+		operations, err = worksheet.RowProblems()
+	}
+	if err != nil {
+		return err
+	}
 
-	// the number of columns is determined by the length of the first line
-	numCols := len(lines[0])
+	totalSum, err := sumOperations(operations, operators, forceBig)
+	if err != nil {
+		return err
+	}
+	fmt.Println("Total Sum of all operations:", totalSum)
+	return nil
+}
 
-	columnBytes := make([][]byte, numCols)
-	for i := 0; i < numCols; i++ {
-		columnBytes[i] = make([]byte, 0)
-		for _, line := range lines {
-			if len(line) > 0 {
-				columnBytes[i] = append(columnBytes[i], line[i])
-			}
-		}
+// Convert reads stdin and writes it in another format:
+//   - "lines": a worksheet (read row-wise or the cephalopod way) as one problem per line
+//   - "rows": one problem per line as a worksheet laid out for the row-wise reading
+//   - "columns": one problem per line as a worksheet laid out for the cephalopod reading
+func Convert(format string, columns bool) error {
+	input, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return err
 	}
 
-	// scan from right to left, parse the operand and operators
-	operations := make([]Operation, 0)
-	var currentOp Operation = Operation{operands: make([]int, 0)}
-	for col := numCols - 1; col >= 0; col-- {
-		colStr := string(columnBytes[col])
-		colStr = strings.TrimSpace(colStr)
-		// fmt.Println("checking colStr", colStr)
-		if colStr == "" {
-			continue
+	if format == "lines" {
+		worksheet, err := NewWorksheet(string(input))
+		if err != nil {
+			return err
 		}
-
-		// see if the last character is an operator
-		if colStr[len(colStr)-1] == '+' || colStr[len(colStr)-1] == '*' {
-			// this is the last column of the current operation
-			currentOp.operator = colStr[len(colStr)-1:]
-
-			restStr := strings.TrimSpace(colStr[:len(colStr)-1])
-			value, _ := strconv.Atoi(restStr)
-			currentOp.operands = append(currentOp.operands, value)
-			operations = append(operations, currentOp)
-			currentOp = Operation{operands: make([]int, 0)}
+		var operations []Operation
+		if columns {
+			operations, err = worksheet.ColumnProblems()
 		} else {
-			value, _ := strconv.Atoi(colStr)
-			currentOp.operands = append(currentOp.operands, value)
+			operations, err = worksheet.RowProblems()
+		}
+		if err != nil {
+			return err
 		}
+		fmt.Print(FormatProblems(operations))
+		return nil
 	}
 
-	totalSum := 0
-	for _, op := range operations {
-		// fmt.Println("Operation:", op.operator, "Operands:", op.operands, "Result:", op.Apply())
-		totalSum += op.Apply()
+	operations, err := ParseProblems(string(input), NewRegistry())
+	if err != nil {
+		return err
 	}
+	switch format {
+	case "rows":
+		fmt.Print(RenderRows(operations))
+	case "columns":
+		fmt.Print(RenderColumns(operations))
+	default:
+		return fmt.Errorf("unknown format %q, expected lines, rows or columns", format)
+	}
+	return nil
+}
 
-	fmt.Println("Total Sum of all operations:", totalSum)
+func Part1() {
+	if err := Solve(false, NewRegistry(), false); err != nil {
+		panic(err)
+	}
+}
+
+func Part2() {
+	if err := Solve(true, NewRegistry(), false); err != nil {
+		panic(err)
+	}
 }
 
 func main() {
+	part := flag.Int("part", 2, "which part to solve (1 or 2)")
+	forceBig := flag.Bool("big", false, "use math/big for every problem, not only for those that overflow")
+	convert := flag.String("convert", "", "convert stdin instead of solving: lines, rows or columns")
+	flag.Parse()
+
+	if *convert != "" {
+		if err := Convert(*convert, *part != 1); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	fmt.Println("--- Day 6: Trash Compactor ---")
-	//Part1()
-	Part2()
+	if err := Solve(*part != 1, NewRegistry(), *forceBig); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+)
+
+// OperatorFunc combines the result so far with the next operand. It returns ErrOverflow
+// when the result doesn't fit in an int, so that the problem can be redone with big numbers.
+type OperatorFunc func(acc, operand int) (int, error)
+
+// BigOperatorFunc is the math/big version of an OperatorFunc. It must not modify its arguments.
+type BigOperatorFunc func(acc, operand *big.Int) (*big.Int, error)
+
+// Operator is an operator usable on the worksheet. Big is optional: without it a
+// problem that overflows is an error instead of being redone with big numbers.
+type Operator struct {
+	Int OperatorFunc
+	Big BigOperatorFunc
+}
+
+// Registry maps operator symbols, as written on the worksheet, to their functions.
+type Registry map[string]Operator
+
+var (
+	ErrOverflow       = errors.New("integer overflow")
+	errDivisionByZero = errors.New("division by zero")
+)
+
+func checkedAdd(a, b int) (int, error) {
+	s := a + b
+	if (a > 0 && b > 0 && s < 0) || (a < 0 && b < 0 && s >= 0) {
+		return 0, ErrOverflow
+	}
+	return s, nil
+}
+
+func checkedSub(a, b int) (int, error) {
+	d := a - b
+	if (a >= 0 && b < 0 && d < 0) || (a < 0 && b > 0 && d >= 0) {
+		return 0, ErrOverflow
+	}
+	return d, nil
+}
+
+func checkedMul(a, b int) (int, error) {
+	if a == 0 || b == 0 {
+		return 0, nil
+	}
+	p := a * b
+	if p/b != a || (a == -1 && b == math.MinInt) || (b == -1 && a == math.MinInt) {
+		return 0, ErrOverflow
+	}
+	return p, nil
+}
+
+func checkedAbs(a int) (int, error) {
+	if a == math.MinInt {
+		return 0, ErrOverflow
+	}
+	if a < 0 {
+		return -a, nil
+	}
+	return a, nil
+}
+
+func gcd(a, b int) (int, error) {
+	a, err := checkedAbs(a)
+	if err != nil {
+		return 0, err
+	}
+	b, err = checkedAbs(b)
+	if err != nil {
+		return 0, err
+	}
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a, nil
+}
+
+func lcm(a, b int) (int, error) {
+	if a == 0 || b == 0 {
+		return 0, nil
+	}
+	g, err := gcd(a, b)
+	if err != nil {
+		return 0, err
+	}
+	l, err := checkedMul(a/g, b)
+	if err != nil {
+		return 0, err
+	}
+	return checkedAbs(l)
+}
+
+func bigLcm(a, b *big.Int) (*big.Int, error) {
+	if a.Sign() == 0 || b.Sign() == 0 {
+		return new(big.Int), nil
+	}
+	g := new(big.Int).GCD(nil, nil, a, b)
+	l := new(big.Int).Quo(a, g)
+	l.Mul(l, b)
+	return l.Abs(l), nil
+}
+
+func bigMin(a, b *big.Int) *big.Int {
+	if a.Cmp(b) <= 0 {
+		return a
+	}
+	return b
+}
+
+func bigMax(a, b *big.Int) *big.Int {
+	if a.Cmp(b) >= 0 {
+		return a
+	}
+	return b
+}
+
+// the operators every registry starts with
+var builtinOperators = Registry{
+	"+": {
+		Int: checkedAdd,
+		Big: func(acc, x *big.Int) (*big.Int, error) { return new(big.Int).Add(acc, x), nil },
+	},
+	"*": {
+		Int: checkedMul,
+		Big: func(acc, x *big.Int) (*big.Int, error) { return new(big.Int).Mul(acc, x), nil },
+	},
+	"-": {
+		Int: checkedSub,
+		Big: func(acc, x *big.Int) (*big.Int, error) { return new(big.Int).Sub(acc, x), nil },
+	},
+	"/": {
+		Int: func(acc, x int) (int, error) {
+			if x == 0 {
+				return 0, errDivisionByZero
+			}
+			if acc == math.MinInt && x == -1 {
+				return 0, ErrOverflow
+			}
+			return acc / x, nil
+		},
+		Big: func(acc, x *big.Int) (*big.Int, error) {
+			if x.Sign() == 0 {
+				return nil, errDivisionByZero
+			}
+			// Quo truncates towards zero like int division
+			return new(big.Int).Quo(acc, x), nil
+		},
+	},
+	"min": {
+		Int: func(acc, x int) (int, error) { return min(acc, x), nil },
+		Big: func(acc, x *big.Int) (*big.Int, error) { return bigMin(acc, x), nil },
+	},
+	"max": {
+		Int: func(acc, x int) (int, error) { return max(acc, x), nil },
+		Big: func(acc, x *big.Int) (*big.Int, error) { return bigMax(acc, x), nil },
+	},
+	"gcd": {
+		Int: gcd,
+		Big: func(acc, x *big.Int) (*big.Int, error) { return new(big.Int).GCD(nil, nil, acc, x), nil },
+	},
+	"lcm": {
+		Int: lcm,
+		Big: bigLcm,
+	},
+}
+
+// NewRegistry returns a registry holding the built-in operators: + * - / min max gcd lcm.
+func NewRegistry() Registry {
+	r := make(Registry, len(builtinOperators))
+	for symbol, op := range builtinOperators {
+		r[symbol] = op
+	}
+	return r
+}
+
+// Register adds an operator that only works on ints; symbols can't contain spaces and
+// can't be registered twice.
+func (r Registry) Register(symbol string, f OperatorFunc) error {
+	return r.RegisterBig(symbol, f, nil)
+}
+
+// RegisterBig adds an operator together with its math/big version, used when the int version overflows.
+func (r Registry) RegisterBig(symbol string, f OperatorFunc, bigF BigOperatorFunc) error {
+	if symbol == "" {
+		return fmt.Errorf("empty operator symbol")
+	}
+	if f == nil && bigF == nil {
+		return fmt.Errorf("operator %q has no function", symbol)
+	}
+	for _, c := range symbol {
+		if c == ' ' || c == '\t' {
+			return fmt.Errorf("operator symbol %q contains whitespace", symbol)
+		}
+	}
+	if _, ok := r[symbol]; ok {
+		return fmt.Errorf("operator %q is already registered", symbol)
+	}
+	r[symbol] = Operator{Int: f, Big: bigF}
+	return nil
+}
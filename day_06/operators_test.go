@@ -0,0 +1,97 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuiltinOperators(t *testing.T) {
+	tests := []struct {
+		operator string
+		operands []int
+		expected int
+	}{
+		{"+", []int{1, 2, 3}, 6},
+		{"*", []int{2, 3, 4}, 24},
+		{"-", []int{10, 3, 2}, 5},
+		{"/", []int{100, 5, 2}, 10},
+		{"min", []int{7, 3, 9}, 3},
+		{"max", []int{7, 3, 9}, 9},
+		{"gcd", []int{12, 18, 27}, 3},
+		{"lcm", []int{4, 6, 10}, 60},
+		{"-", []int{42}, 42},
+	}
+
+	for _, test := range tests {
+		result, err := Operation{operator: test.operator, operands: test.operands}.Apply()
+		if err != nil {
+			t.Errorf("%s %v: unexpected error %v", test.operator, test.operands, err)
+			continue
+		}
+		if result != test.expected {
+			t.Errorf("%s %v = %d; want %d", test.operator, test.operands, result, test.expected)
+		}
+	}
+}
+
+func TestOperatorErrors(t *testing.T) {
+	_, err := Operation{operator: "%", operands: []int{1, 2}, column: 17}.Apply()
+	if err == nil || !strings.Contains(err.Error(), "column 17") {
+		t.Errorf("expected an unknown operator error mentioning column 17, got %v", err)
+	}
+
+	if _, err := (Operation{operator: "/", operands: []int{1, 0}, column: 3}).Apply(); err == nil {
+		t.Errorf("expected a division by zero error")
+	}
+}
+
+func TestRegisterOperator(t *testing.T) {
+	operators := NewRegistry()
+	pow := func(acc, x int) (int, error) {
+		result := 1
+		for i := 0; i < x; i++ {
+			result *= acc
+		}
+		return result, nil
+	}
+	if err := operators.Register("^", pow); err != nil {
+		t.Fatal(err)
+	}
+	if err := operators.Register("+", pow); err == nil {
+		t.Errorf("expected an error when registering + twice")
+	}
+	if _, ok := builtinOperators["^"]; ok {
+		t.Errorf("registering must not change the built-in operators")
+	}
+
+	// (2^3)^2, left to right
+	result, err := Operation{operator: "^", operands: []int{2, 3, 2}}.ApplyWith(operators)
+	if err != nil || result != 64 {
+		t.Errorf("^ [2 3 2] = %d (%v); want 64", result, err)
+	}
+}
+
+func TestNonCommutativeReadingOrders(t *testing.T) {
+	ws, err := NewWorksheet("12 9\n 3 4\n-  /\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := ws.RowProblems()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 12 - 3 and 9 / 4
+	if total, err := sumOperations(rows, NewRegistry(), false); err != nil || total != IntValue(9+2) {
+		t.Errorf("row-wise total = %d (%v); want 11", total, err)
+	}
+
+	columns, err := ws.ColumnProblems()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 94 is a single operand; 23 - 1, reading the rightmost column first
+	if total, err := sumOperations(columns, NewRegistry(), false); err != nil || total != IntValue(94+22) {
+		t.Errorf("column-wise total = %d (%v); want 116", total, err)
+	}
+}
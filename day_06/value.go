@@ -0,0 +1,57 @@
+package main
+
+import (
+	"math/big"
+)
+
+// Value is a worksheet result. It is kept as an int and switches to math/big once
+// a problem or the grand total no longer fits. Values are never modified in place.
+type Value struct {
+	small int
+	big   *big.Int // set when the value is held as a big number
+}
+
+func IntValue(n int) Value {
+	return Value{small: n}
+}
+
+func BigValue(n *big.Int) Value {
+	return Value{big: n}
+}
+
+func (v Value) IsBig() bool {
+	return v.big != nil
+}
+
+// Int returns the value and whether it fits in an int.
+func (v Value) Int() (int, bool) {
+	if v.big == nil {
+		return v.small, true
+	}
+	if v.big.IsInt64() && int64(int(v.big.Int64())) == v.big.Int64() {
+		return int(v.big.Int64()), true
+	}
+	return 0, false
+}
+
+// Big returns the value as a new big.Int.
+func (v Value) Big() *big.Int {
+	if v.big == nil {
+		return big.NewInt(int64(v.small))
+	}
+	return new(big.Int).Set(v.big)
+}
+
+// Add returns v + o, switching to math/big if the sum overflows.
+func (v Value) Add(o Value) Value {
+	if v.big == nil && o.big == nil {
+		if sum, err := checkedAdd(v.small, o.small); err == nil {
+			return Value{small: sum}
+		}
+	}
+	return Value{big: new(big.Int).Add(v.Big(), o.Big())}
+}
+
+func (v Value) String() string {
+	return v.Big().String()
+}
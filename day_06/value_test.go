@@ -0,0 +1,110 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestCheckedArithmetic(t *testing.T) {
+	if _, err := checkedAdd(math.MaxInt, 1); !errors.Is(err, ErrOverflow) {
+		t.Errorf("MaxInt + 1 should overflow")
+	}
+	if _, err := checkedSub(math.MinInt, 1); !errors.Is(err, ErrOverflow) {
+		t.Errorf("MinInt - 1 should overflow")
+	}
+	if _, err := checkedMul(math.MaxInt/2+1, 2); !errors.Is(err, ErrOverflow) {
+		t.Errorf("(MaxInt/2+1) * 2 should overflow")
+	}
+	if _, err := checkedMul(math.MinInt, -1); !errors.Is(err, ErrOverflow) {
+		t.Errorf("MinInt * -1 should overflow")
+	}
+	if p, err := checkedMul(-3, 7); err != nil || p != -21 {
+		t.Errorf("-3 * 7 = %d (%v)", p, err)
+	}
+	if v := IntValue(math.MaxInt).Add(IntValue(1)); !v.IsBig() || v.String() != "9223372036854775808" {
+		t.Errorf("MaxInt + 1 = %v; want 9223372036854775808 as a big value", v)
+	}
+}
+
+func TestOverflowingWorksheet(t *testing.T) {
+	// 10^18 * 10 * 10 doesn't fit in an int64, the second problem still does
+	ws, err := NewWorksheet(
+		"1000000000000000000 5\n" +
+			"                 10 6\n" +
+			"                 10 7\n" +
+			"*                   *\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	operations, err := ws.RowProblems()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := operations[0].Evaluate(NewRegistry(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !first.IsBig() || first.String() != "100000000000000000000" {
+		t.Errorf("first problem = %v; want 10^20 as a big value", first)
+	}
+	second, err := operations[1].Evaluate(NewRegistry(), false)
+	if err != nil || second.IsBig() || second != IntValue(210) {
+		t.Errorf("second problem = %v (%v); want 210 as an int", second, err)
+	}
+
+	total, err := sumOperations(operations, NewRegistry(), false)
+	if err != nil || total.String() != "100000000000000000210" {
+		t.Errorf("total = %v (%v); want 100000000000000000210", total, err)
+	}
+
+	forced, err := sumOperations(operations, NewRegistry(), true)
+	if err != nil || !forced.IsBig() || forced.String() != total.String() {
+		t.Errorf("forced big total = %v (%v); want %v", forced, err, total)
+	}
+
+	if _, err := operations[0].Apply(); !errors.Is(err, ErrOverflow) {
+		t.Errorf("Apply should report the overflow, got %v", err)
+	}
+}
+
+func TestOversizedOperands(t *testing.T) {
+	// a long column makes a 25 digit operand in the cephalopod reading
+	lines := make([]string, 0)
+	for i := 0; i < 25; i++ {
+		lines = append(lines, "9 1")
+	}
+	lines = append(lines, "+ +")
+	ws, err := NewWorksheet(strings.Join(lines, "\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	operations, err := ws.ColumnProblems()
+	if err != nil {
+		t.Fatal(err)
+	}
+	total, err := sumOperations(operations, NewRegistry(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nines, _ := new(big.Int).SetString(strings.Repeat("9", 25), 10)
+	ones, _ := new(big.Int).SetString(strings.Repeat("1", 25), 10)
+	expected := new(big.Int).Add(nines, ones)
+	if total.Big().Cmp(expected) != 0 {
+		t.Errorf("total = %v; want %v", total, expected)
+	}
+}
+
+func TestOverflowWithoutBigOperator(t *testing.T) {
+	operators := NewRegistry()
+	if err := operators.Register("**", func(acc, x int) (int, error) { return checkedMul(acc, x) }); err != nil {
+		t.Fatal(err)
+	}
+	op := Operation{operator: "**", operands: []int{math.MaxInt, 2}, column: 4}
+	if _, err := op.Evaluate(operators, false); !errors.Is(err, ErrOverflow) {
+		t.Errorf("expected an overflow error for an int-only operator, got %v", err)
+	}
+}
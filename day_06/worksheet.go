@@ -0,0 +1,169 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Worksheet is the raw character matrix of the homework: some lines of numbers
+// followed by a line of operators. Problems are separated by columns that are blank
+// on every line. Short lines are treated as if they were padded with spaces.
+type Worksheet struct {
+	numberLines  []string // padded to width
+	operatorLine string   // padded to width
+	width        int
+	problems     []columnSpan // left to right
+}
+
+// the columns [start, end) of one problem
+type columnSpan struct {
+	start int
+	end   int
+}
+
+func ParseWorksheet(r io.Reader) (*Worksheet, error) {
+	input, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return NewWorksheet(string(input))
+}
+
+// NewWorksheet builds a worksheet from its text. Blank lines are ignored and the
+// last non-blank line is taken as the operator line.
+func NewWorksheet(input string) (*Worksheet, error) {
+	lines := make([]string, 0)
+	width := 0
+	for _, line := range strings.Split(input, "\n") {
+		line = strings.TrimRight(line, " \r\t")
+		if line == "" {
+			continue
+		}
+		lines = append(lines, line)
+		width = max(width, len(line))
+	}
+	if len(lines) < 2 {
+		return nil, fmt.Errorf("a worksheet needs at least one line of numbers and a line of operators, got %d lines", len(lines))
+	}
+
+	for i, line := range lines {
+		lines[i] = line + strings.Repeat(" ", width-len(line))
+	}
+
+	ws := &Worksheet{
+		numberLines:  lines[:len(lines)-1],
+		operatorLine: lines[len(lines)-1],
+		width:        width,
+	}
+
+	start := -1
+	for col := 0; col <= width; col++ {
+		if col < width && !ws.blankColumn(col) {
+			if start < 0 {
+				start = col
+			}
+			continue
+		}
+		if start >= 0 {
+			ws.problems = append(ws.problems, columnSpan{start: start, end: col})
+			start = -1
+		}
+	}
+	return ws, nil
+}
+
+func (ws *Worksheet) blankColumn(col int) bool {
+	if ws.operatorLine[col] != ' ' {
+		return false
+	}
+	for _, line := range ws.numberLines {
+		if line[col] != ' ' {
+			return false
+		}
+	}
+	return true
+}
+
+func (ws *Worksheet) operatorOf(span columnSpan) (string, error) {
+	operator := strings.TrimSpace(ws.operatorLine[span.start:span.end])
+	if operator == "" {
+		return "", fmt.Errorf("problem at column %d has no operator", span.start+1)
+	}
+	return operator, nil
+}
+
+// parseOperand parses an integer, returning it as a big.Int when it doesn't fit in an int
+func parseOperand(s string) (int, *big.Int, bool) {
+	value, err := strconv.Atoi(s)
+	if err == nil {
+		return value, nil, true
+	}
+	if errors.Is(err, strconv.ErrRange) {
+		if large, ok := new(big.Int).SetString(s, 10); ok {
+			return 0, large, true
+		}
+	}
+	return 0, nil, false
+}
+
+// RowProblems reads the problems left to right, taking each line of numbers as one
+// operand, from top to bottom.
+func (ws *Worksheet) RowProblems() ([]Operation, error) {
+	operations := make([]Operation, 0, len(ws.problems))
+	for _, span := range ws.problems {
+		operator, err := ws.operatorOf(span)
+		if err != nil {
+			return nil, err
+		}
+		op := Operation{operator: operator, operands: make([]int, 0, len(ws.numberLines)), column: span.start + 1}
+		for i, line := range ws.numberLines {
+			field := strings.TrimSpace(line[span.start:span.end])
+			if field == "" {
+				continue
+			}
+			small, large, ok := parseOperand(field)
+			if !ok {
+				return nil, fmt.Errorf("invalid operand %q at line %d, column %d", field, i+1, span.start+1)
+			}
+			op.appendOperand(small, large)
+		}
+		operations = append(operations, op)
+	}
+	return operations, nil
+}
+
+// ColumnProblems reads the problems the cephalopod way: right to left, with every column
+// of digits read top to bottom as one operand, starting from the rightmost column.
+func (ws *Worksheet) ColumnProblems() ([]Operation, error) {
+	operations := make([]Operation, 0, len(ws.problems))
+	for p := len(ws.problems) - 1; p >= 0; p-- {
+		span := ws.problems[p]
+		operator, err := ws.operatorOf(span)
+		if err != nil {
+			return nil, err
+		}
+		op := Operation{operator: operator, operands: make([]int, 0, span.end-span.start), column: span.start + 1}
+		for col := span.end - 1; col >= span.start; col-- {
+			var digits strings.Builder
+			for _, line := range ws.numberLines {
+				if line[col] != ' ' {
+					digits.WriteByte(line[col])
+				}
+			}
+			if digits.Len() == 0 {
+				continue
+			}
+			small, large, ok := parseOperand(digits.String())
+			if !ok {
+				return nil, fmt.Errorf("invalid operand %q at column %d", digits.String(), col+1)
+			}
+			op.appendOperand(small, large)
+		}
+		operations = append(operations, op)
+	}
+	return operations, nil
+}
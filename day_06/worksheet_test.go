@@ -0,0 +1,100 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+const exampleWorksheet = "123 328  51 64 \n" +
+	" 45 64  387 23 \n" +
+	"  6 98  215 314\n" +
+	"*   +   *   +  \n"
+
+func TestWorksheetReadingOrders(t *testing.T) {
+	ws, err := NewWorksheet(exampleWorksheet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := ws.RowProblems()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedRows := []Operation{
+		{operator: "*", operands: []int{123, 45, 6}, column: 1},
+		{operator: "+", operands: []int{328, 64, 98}, column: 5},
+		{operator: "*", operands: []int{51, 387, 215}, column: 9},
+		{operator: "+", operands: []int{64, 23, 314}, column: 13},
+	}
+	if !reflect.DeepEqual(rows, expectedRows) {
+		t.Errorf("RowProblems() = %v; want %v", rows, expectedRows)
+	}
+	if total, err := sumOperations(rows, NewRegistry(), false); err != nil || total != IntValue(4277556) {
+		t.Errorf("row-wise total = %d; want 4277556", total)
+	}
+
+	columns, err := ws.ColumnProblems()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedColumns := []Operation{
+		{operator: "+", operands: []int{4, 431, 623}, column: 13},
+		{operator: "*", operands: []int{175, 581, 32}, column: 9},
+		{operator: "+", operands: []int{8, 248, 369}, column: 5},
+		{operator: "*", operands: []int{356, 24, 1}, column: 1},
+	}
+	if !reflect.DeepEqual(columns, expectedColumns) {
+		t.Errorf("ColumnProblems() = %v; want %v", columns, expectedColumns)
+	}
+	if total, err := sumOperations(columns, NewRegistry(), false); err != nil || total != IntValue(3263827) {
+		t.Errorf("column-wise total = %d; want 3263827", total)
+	}
+}
+
+func TestWorksheetRaggedLines(t *testing.T) {
+	// trailing spaces stripped from every line, and trailing blank lines
+	ragged := "123 328  51 64\n" +
+		" 45 64  387 23\n" +
+		"  6 98  215 314\n" +
+		"*   +   *   +\n\n\n"
+	ws, err := NewWorksheet(ragged)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := ws.RowProblems()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total, err := sumOperations(rows, NewRegistry(), false); err != nil || total != IntValue(4277556) {
+		t.Errorf("row-wise total = %d; want 4277556", total)
+	}
+	columns, err := ws.ColumnProblems()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total, err := sumOperations(columns, NewRegistry(), false); err != nil || total != IntValue(3263827) {
+		t.Errorf("column-wise total = %d; want 3263827", total)
+	}
+}
+
+func TestWorksheetErrors(t *testing.T) {
+	if _, err := NewWorksheet("\n\n"); err == nil {
+		t.Errorf("expected an error for an empty worksheet")
+	}
+
+	ws, err := NewWorksheet("12 x4\n3  5\n+  *\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ws.RowProblems(); err == nil {
+		t.Errorf("expected an error for a non-numeric operand")
+	}
+
+	ws, err = NewWorksheet("12 34\n3  5\n+   \n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ws.ColumnProblems(); err == nil {
+		t.Errorf("expected an error for a missing operator")
+	}
+}
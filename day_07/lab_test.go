@@ -0,0 +1,169 @@
+package main
+
+import (
+	"math/big"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const exampleLab = `.......S.......
+...............
+.......^.......
+...............
+......^.^......
+...............
+.....^.^.^.....
+...............
+....^.^...^....
+...............
+...^.^...^.^...
+...............
+..^...^.....^..
+...............
+.^.^.^.^.^...^.
+...............
+`
+
+// recursive count with a local memo, as CountPaths used to do it
+func referencePaths(lab *Lab, pos Position, memo map[Position]int) int {
+	if n, ok := memo[pos]; ok {
+		return n
+	}
+	row, col := pos.row, pos.col
+	for row < len(lab.Rows) && lab.Rows[row][col] != splitterChar {
+		row++
+	}
+	if row == len(lab.Rows) {
+		memo[pos] = 1
+		return 1
+	}
+	n := 0
+	if col > 0 {
+		n += referencePaths(lab, Position{row: row, col: col - 1}, memo)
+	}
+	if col < len(lab.Rows[row])-1 {
+		n += referencePaths(lab, Position{row: row, col: col + 1}, memo)
+	}
+	memo[pos] = n
+	return n
+}
+
+// randomLab puts splitters on every other row, never next to each other
+func randomLab(rng *rand.Rand, nRows, nCols int) Lab {
+	var sb strings.Builder
+	for row := 0; row < nRows; row++ {
+		for col := 0; col < nCols; col++ {
+			switch {
+			case row == 0 && col == nCols/2:
+				sb.WriteByte(startChar)
+			case row > 0 && row%2 == 0 && col%2 == row/2%2 && rng.Intn(3) > 0:
+				sb.WriteByte(splitterChar)
+			default:
+				sb.WriteByte('.')
+			}
+		}
+		sb.WriteByte('\n')
+	}
+	return NewLab(sb.String())
+}
+
+func TestCountPathsExample(t *testing.T) {
+	lab := NewLab(exampleLab)
+	start, err := lab.FindStart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := CountPaths(&lab, start); got.Cmp(big.NewInt(40)) != 0 {
+		t.Errorf("CountPaths() = %s; want 40", got)
+	}
+	// no state is kept between calls
+	other := NewLab("S.\n^.\n..\n")
+	if got := CountPaths(&other, Position{row: 0, col: 0}); got.Cmp(big.NewInt(1)) != 0 {
+		t.Errorf("CountPaths() on a second lab = %s; want 1", got)
+	}
+}
+
+func TestCountPathsMatchesRecursion(t *testing.T) {
+	rng := rand.New(rand.NewSource(7))
+	for i := 0; i < 200; i++ {
+		lab := randomLab(rng, 2+rng.Intn(20), 1+rng.Intn(15))
+		start, err := lab.FindStart()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hit, n := len(SweepTimelines(&lab, start).SplitterHits), CountSplitters(&lab, start); hit != n {
+			t.Fatalf("SweepTimelines() hits %d splitters, CountSplitters() %d on\n%s", hit, n, lab.Rows)
+		}
+		want := referencePaths(&lab, start, make(map[Position]int))
+		if got := CountPaths(&lab, start); got.Cmp(big.NewInt(int64(want))) != 0 {
+			t.Fatalf("CountPaths() = %s; want %d on\n%s", got, want, lab.Rows)
+		}
+	}
+}
+
+func TestCountPathsDoesNotOverflow(t *testing.T) {
+	// splitters on every other column of every other row, shifted by one each time, so
+	// that every split lands right above the next splitters: 2^98 timelines
+	nCols := 201
+	var sb strings.Builder
+	sb.WriteString(strings.Repeat(".", nCols/2) + "S" + strings.Repeat(".", nCols/2) + "\n")
+	for row := 1; row < 200; row++ {
+		for col := 0; col < nCols; col++ {
+			if row%2 == 0 && col%2 == row/2%2 {
+				sb.WriteByte(splitterChar)
+			} else {
+				sb.WriteByte('.')
+			}
+		}
+		sb.WriteByte('\n')
+	}
+	lab := NewLab(sb.String())
+	start, _ := lab.FindStart()
+	got := CountPaths(&lab, start)
+	if got.IsInt64() {
+		t.Errorf("CountPaths() = %s; expected more timelines than an int64 holds", got)
+	}
+	if want := new(big.Int).Lsh(big.NewInt(1), 98); got.Cmp(want) != 0 {
+		t.Errorf("CountPaths() = %s; want %s", got, want)
+	}
+}
+
+func TestSweepTimelinesExample(t *testing.T) {
+	lab := NewLab(exampleLab)
+	start, _ := lab.FindStart()
+	sweep := SweepTimelines(&lab, start)
+
+	exits := make([]int64, len(sweep.Exits))
+	for col, n := range sweep.Exits {
+		exits[col] = n.Int64()
+	}
+	expectedExits := []int64{1, 0, 2, 0, 10, 0, 11, 0, 11, 0, 2, 1, 1, 0, 1}
+	if !reflect.DeepEqual(exits, expectedExits) {
+		t.Errorf("Exits = %v; want %v", exits, expectedExits)
+	}
+
+	// the splitters hit are the ones Part1 counts
+	if hit := sweep.SplittersHit(); len(hit) != 21 {
+		t.Errorf("%d splitters hit; want 21", len(hit))
+	}
+	for pos, want := range map[Position]int64{{2, 7}: 1, {6, 7}: 2, {8, 6}: 3, {14, 7}: 7} {
+		if got := sweep.SplitterHits[pos]; got == nil || got.Int64() != want {
+			t.Errorf("SplitterHits[%v] = %v; want %d", pos, got, want)
+		}
+	}
+
+	// the density of a splitter cell is the number of timelines reaching it
+	if got := sweep.Density[14][7]; got.Int64() != 7 {
+		t.Errorf("Density[14][7] = %s; want 7", got)
+	}
+
+	heatmap := sweep.Heatmap(&lab)
+	if lines := strings.Count(heatmap, "\n"); lines != len(lab.Rows) {
+		t.Errorf("heatmap has %d lines; want %d", lines, len(lab.Rows))
+	}
+	if !strings.Contains(heatmap, ".......") {
+		t.Errorf("heatmap should leave the cells no timeline reaches as they are:\n%s", heatmap)
+	}
+}
@@ -2,9 +2,11 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"io"
 	"log"
+	"math/big"
 	"os"
 	"strings"
 
@@ -24,15 +26,13 @@ type Position struct {
 	col int
 }
 
+// FindStart returns the first source of the lab, reading top to bottom and left to right.
 func (lab *Lab) FindStart() (Position, error) {
-	// just assume start is always at row 0
-	for col, char := range lab.Rows[0] {
-		if byte(char) == startChar {
-			fmt.Printf("Found start at (0,%d)\n", col)
-			return Position{row: 0, col: col}, nil
-		}
+	sources := lab.FindSources()
+	if len(sources) == 0 {
+		return Position{}, errNoSource
 	}
-	return Position{}, fmt.Errorf("start position not found")
+	return sources[0], nil
 }
 
 func ParseInput() Lab {
@@ -41,8 +41,12 @@ func ParseInput() Lab {
 	if err != nil {
 		panic(err)
 	}
+	return NewLab(string(input))
+}
 
-	lines := strings.Split(string(input), "\n")
+// NewLab builds a lab from its text, stopping at the first blank line.
+func NewLab(input string) Lab {
+	lines := strings.Split(input, "\n")
 	lab := Lab{Rows: make([][]byte, 0)}
 	for _, line := range lines {
 		if strings.TrimSpace(line) == "" {
@@ -59,58 +63,70 @@ func ParseInput() Lab {
 	return lab
 }
 
-func Part1() {
-	lab := ParseInput()
-
+// CountSplitters returns how many splitters the beams from the given sources hit, counting
+// every splitter once even when beams from several sources hit it. Like SweepTimelines, a
+// source sitting on a splitter is split right away.
+func CountSplitters(lab *Lab, sources ...Position) int {
 	visitedSpliters := make(map[Position]bool)
 	visitedPositions := make(map[Position]bool)
 
-	startPos, _ := lab.FindStart()
 	q := queue.Queue[Position]{}
-	q.Enqueue(startPos)
+	for _, source := range sources {
+		if !q.Contains(source) {
+			q.Enqueue(source)
+		}
+	}
 
 	for q.Len() > 0 {
 		v, _ := q.Dequeue()
 		visitedPositions[v] = true
 
 		// from position v, travel downward in the lab, until hitting a splitter or the bottom
-		row := v.row + 1
+		row := v.row
 		col := v.col
 		for row < len(lab.Rows) {
-			char := lab.Rows[row][col]
+			char := lab.at(Position{row: row, col: col})
 			// mark position as visited
 			visitedPositions[Position{row: row, col: col}] = true
 
 			if char == splitterChar {
-				// enqueue left and right positions, if they have not been visited before
-				if col > 0 {
-					candidatePos := Position{row: row, col: col - 1}
+				// enqueue the positions below left and right, where the split beams carry on,
+				// if they have not been visited before
+				if col > 0 && row+1 < len(lab.Rows) {
+					candidatePos := Position{row: row + 1, col: col - 1}
 					if !visitedPositions[candidatePos] && !q.Contains(candidatePos) {
 						q.Enqueue(candidatePos)
 					}
 				}
 
-				if col < len(lab.Rows[row])-1 {
-					candidatePos := Position{row: row, col: col + 1}
+				if col < len(lab.Rows[row])-1 && row+1 < len(lab.Rows) {
+					candidatePos := Position{row: row + 1, col: col + 1}
 					if !visitedPositions[candidatePos] && !q.Contains(candidatePos) {
-						q.Enqueue(Position{row: row, col: col + 1})
+						q.Enqueue(candidatePos)
 					}
 				}
 
 				// mark splitter as visited
 				visitedSpliters[Position{row: row, col: col}] = true
 				break
-
-			} else if char == '.' || char == startChar {
-				// continue downward
-				row++
 			}
+			// anything else, another source included, lets the beam continue downward
+			row++
 		}
 
 	}
+	return len(visitedSpliters)
+}
+
+func Part1(lab *Lab, sources []Position) {
+	if len(sources) > 1 {
+		for _, source := range sources {
+			fmt.Printf("Part 1: Source at (%d,%d) hits %d splitters\n", source.row, source.col, CountSplitters(lab, source))
+		}
+	}
 
 	// print number of visited splitters
-	fmt.Printf("Part 1: Number of visited splitters: %d\n", len(visitedSpliters))
+	fmt.Printf("Part 1: Number of visited splitters: %d\n", CountSplitters(lab, sources...))
 
 }
 
@@ -165,58 +181,122 @@ func Part2_old() {
 
 }
 
-var pathCountMemo map[Position]int = make(map[Position]int)
-
-// count the number of paths from the given position to the bottom of the lab
-func CountPaths(lab *Lab, pos Position) (nPaths int) {
-	// check if we can go all the way to the bottom from the current position
+// CountPaths counts the timelines from the given position to the bottom of the lab, see SweepTimelines.
+func CountPaths(lab *Lab, pos Position) *big.Int {
+	return SweepTimelines(lab, pos).Total
+}
 
-	if _, ok := pathCountMemo[pos]; ok {
-		// log.Printf("already known paths from position %+v: %d\n", pos, pathCountMemo[pos])
-		return pathCountMemo[pos]
+func Part2(lab *Lab, sources []Position, stats bool, heatmap bool) {
+	if len(sources) > 1 {
+		for _, source := range sources {
+			fmt.Printf("Part 2: Source at (%d,%d) has %d paths to the bottom\n", source.row, source.col, CountPaths(lab, source))
+		}
 	}
-
-	// go down until hitting a splitter or the bottom
-	row := pos.row
-	col := pos.col
-	totalRows := len(lab.Rows)
-	for row < totalRows && lab.Rows[row][col] != splitterChar {
-		row++
+	sweep := SweepTimelines(lab, sources...)
+	if heatmap {
+		fmt.Print(sweep.Heatmap(lab))
 	}
-
-	// did we hit the bottom?
-	if row == totalRows {
-		// log.Printf("position %+v can hit bottom directly \n", pos)
-		nPaths = 1
-		pathCountMemo[pos] = 1
-		return nPaths
+	if stats {
+		for _, pos := range sweep.SplittersHit() {
+			fmt.Printf("Splitter at (%d,%d): %d timelines\n", pos.row, pos.col, sweep.SplitterHits[pos])
+		}
+		for col, n := range sweep.Exits {
+			if n.Sign() != 0 {
+				fmt.Printf("Column %d: %d timelines exit\n", col, n)
+			}
+		}
 	}
+	fmt.Printf("Part 2: Total distinct paths to the bottom: %d\n", sweep.Total)
+}
 
-	// we hit a splitter at (row, col), explore left and right
-	if col > 0 {
-		leftPos := Position{row: row, col: col - 1}
-		nPaths += CountPaths(lab, leftPos)
+// Part3 traces the beams through a manifold that may also hold mirrors, absorbers and mergers,
+// and reports the energized cells, where the beams leave and any loops.
+func Part3(lab *Lab, sources []Position, render bool) {
+	starts := make([]Beam, len(sources))
+	for i, source := range sources {
+		starts[i] = Beam{Pos: source, Dir: Down}
+	}
+	report := lab.Trace(starts...)
+	if render {
+		fmt.Print(lab.RenderEnergized(report))
 	}
-	if col < len(lab.Rows[row])-1 {
-		rightPos := Position{row: row, col: col + 1}
-		nPaths += CountPaths(lab, rightPos)
+	fmt.Printf("Energized cells: %d, splitters hit: %d\n", len(report.Energized), len(report.Splitters))
+	for _, exit := range report.Exits {
+		fmt.Printf("Beam exits at %s\n", exit)
 	}
+	for _, loop := range report.Loops {
+		fmt.Printf("Beam loops at %s\n", loop)
+	}
+}
 
-	pathCountMemo[pos] = nPaths
-	log.Printf("paths from position (%d,%d): %d\n", pos.row, pos.col, nPaths)
-	return nPaths
+// Part4 lets every splitter send the beam either left or right at random, and reports the
+// exact probability of the beam from each source leaving through each column.
+func Part4(lab *Lab, sources []Position, odds SplitterOdds) {
+	for _, source := range sources {
+		if len(sources) > 1 {
+			fmt.Printf("Source at (%d,%d):\n", source.row, source.col)
+		}
+		if err := printDistribution(lab, source, odds); err != nil {
+			log.Fatal(err)
+		}
+	}
 }
 
-func Part2() {
-	lab := ParseInput()
-	startPos, _ := lab.FindStart()
-	fmt.Printf("Part 2: Start position is at (%d,%d)\n", startPos.row, startPos.col)
-	totalPaths := CountPaths(&lab, startPos)
-	fmt.Printf("Part 2: Total distinct paths to the bottom: %d\n", totalPaths)
+func printDistribution(lab *Lab, source Position, odds SplitterOdds) error {
+	dist, err := SweepProbabilities(lab, source, odds)
+	if err != nil {
+		return err
+	}
+	for col, p := range dist.Exits {
+		if p.Sign() != 0 {
+			fmt.Printf("Column %d: %s (%s)\n", col, p.RatString(), p.FloatString(6))
+		}
+	}
+	if dist.OffSide.Sign() != 0 {
+		fmt.Printf("Off the side: %s (%s)\n", dist.OffSide.RatString(), dist.OffSide.FloatString(6))
+	}
+	fmt.Printf("Expected number of splitters visited: %s (%s)\n", dist.ExpectedSplitters.RatString(), dist.ExpectedSplitters.FloatString(6))
+	return nil
 }
 
 func main() {
+	part := flag.Int("part", 2, "which part to solve (1 or 2), 3 to trace beams through mirrors, absorbers and mergers, or 4 for random splitters")
+	render := flag.Bool("render", false, "with -part 3, draw the energized cells")
+	stats := flag.Bool("stats", false, "with -part 2, print the timelines through every splitter and out of every column")
+	heatmap := flag.Bool("heatmap", false, "with -part 2, draw the lab colored by timeline density")
+	odds := NewSplitterOdds(big.NewRat(1, 2))
+	flag.Func("p", "with -part 4, the probability that a splitter sends the beam left, e.g. 1/3 or 0.25 (default 1/2)", func(s string) error {
+		p, ok := new(big.Rat).SetString(s)
+		if !ok {
+			return fmt.Errorf("invalid probability %q", s)
+		}
+		odds.Left = p
+		return checkProbability(p)
+	})
+	flag.Func("override", "with -part 4, the probability for one splitter, as row,col=p; can be repeated", odds.ParseOverride)
+	injected := make([]Position, 0)
+	flag.Func("source", "an extra beam source, as row,col; can be repeated", func(s string) error {
+		pos, err := ParseSource(s)
+		injected = append(injected, pos)
+		return err
+	})
+	flag.Parse()
+
 	fmt.Println("--- Day 7: Laboratories ---")
-	//Part1()
-	Part2()
+	lab := ParseInput()
+	sources, err := lab.Sources(injected)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	switch *part {
+	case 1:
+		Part1(&lab, sources)
+	case 3:
+		Part3(&lab, sources, *render)
+	case 4:
+		Part4(&lab, sources, odds)
+	default:
+		Part2(&lab, sources, *stats, *heatmap)
+	}
 }
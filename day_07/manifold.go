@@ -0,0 +1,244 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// the other elements a manifold can hold, besides the start, the splitters and empty space
+const (
+	emptyChar     byte = '.'
+	mirrorChar    byte = '/'
+	backslashChar byte = '\\'
+	absorberChar  byte = '#'
+)
+
+// mergers send every beam that enters them, from any side, out in one direction.
+// The upward merger is 'A' because '^' is already the splitter.
+var mergerDirections = map[byte]Direction{
+	'v': Down,
+	'<': Left,
+	'>': Right,
+	'A': Up,
+}
+
+type Direction int
+
+const (
+	Up Direction = iota
+	Right
+	Down
+	Left
+)
+
+func (d Direction) String() string {
+	return [...]string{"up", "right", "down", "left"}[d]
+}
+
+func (d Direction) vertical() bool {
+	return d == Up || d == Down
+}
+
+func (d Direction) delta() (int, int) {
+	switch d {
+	case Up:
+		return -1, 0
+	case Right:
+		return 0, 1
+	case Down:
+		return 1, 0
+	default:
+		return 0, -1
+	}
+}
+
+// mirrored returns the direction a beam leaves a mirror in
+func mirrored(mirror byte, d Direction) Direction {
+	if mirror == mirrorChar {
+		// '/': right turns up, up turns right, left turns down, down turns left
+		return [...]Direction{Right, Up, Left, Down}[d]
+	}
+	// '\': right turns down, down turns right, left turns up, up turns left
+	return [...]Direction{Left, Down, Right, Up}[d]
+}
+
+// Beam is a beam entering the cell at Pos, travelling in direction Dir.
+type Beam struct {
+	Pos Position
+	Dir Direction
+}
+
+func (b Beam) String() string {
+	return fmt.Sprintf("(%d,%d) %s", b.Pos.row, b.Pos.col, b.Dir)
+}
+
+// Exit is a beam leaving the manifold: Pos is the last cell it crossed and Dir the side it leaves by.
+type Exit Beam
+
+func (e Exit) String() string {
+	return Beam(e).String()
+}
+
+// BeamReport is what tracing beams through a manifold found.
+type BeamReport struct {
+	Energized map[Position]bool // the cells crossed by at least one beam
+	Splitters map[Position]bool // the splitters hit by at least one beam
+	Exits     []Exit            // sorted by position, then direction
+	Loops     []Beam            // beams that come back to a state they already were in
+}
+
+func (lab *Lab) width() int {
+	width := 0
+	for _, row := range lab.Rows {
+		width = max(width, len(row))
+	}
+	return width
+}
+
+func (lab *Lab) inside(pos Position, width int) bool {
+	return pos.row >= 0 && pos.row < len(lab.Rows) && pos.col >= 0 && pos.col < width
+}
+
+// at returns the element at pos; short rows are read as padded with empty space
+func (lab *Lab) at(pos Position) byte {
+	row := lab.Rows[pos.row]
+	if pos.col >= len(row) {
+		return emptyChar
+	}
+	return row[pos.col]
+}
+
+// step returns the beams leaving the cell that b enters, and the exits when those beams leave the lab.
+func (lab *Lab) step(b Beam, width int) (next []Beam, exits []Exit) {
+	move := func(pos Position, d Direction) {
+		dr, dc := d.delta()
+		to := Position{row: pos.row + dr, col: pos.col + dc}
+		if lab.inside(to, width) {
+			next = append(next, Beam{Pos: to, Dir: d})
+		} else {
+			exits = append(exits, Exit{Pos: pos, Dir: d})
+		}
+	}
+	// a beam pushed sideways by a splitter starts in the cell beside it, keeping its direction
+	beside := func(d Direction) {
+		dr, dc := d.delta()
+		to := Position{row: b.Pos.row + dr, col: b.Pos.col + dc}
+		if lab.inside(to, width) {
+			next = append(next, Beam{Pos: to, Dir: b.Dir})
+		} else {
+			exits = append(exits, Exit{Pos: b.Pos, Dir: d})
+		}
+	}
+
+	char := lab.at(b.Pos)
+	switch {
+	case char == absorberChar:
+	case char == splitterChar && b.Dir.vertical():
+		beside(Left)
+		beside(Right)
+	case char == mirrorChar || char == backslashChar:
+		move(b.Pos, mirrored(char, b.Dir))
+	default:
+		if d, ok := mergerDirections[char]; ok {
+			move(b.Pos, d)
+		} else {
+			// empty space, a start, or a splitter met side on
+			move(b.Pos, b.Dir)
+		}
+	}
+	return next, exits
+}
+
+// Trace follows the beams from the given starting beams until every one of them has left
+// the lab, been absorbed, or joined a path that was already traced. A beam that comes back
+// to a cell in the same direction as before is in a loop and is reported instead of followed.
+func (lab *Lab) Trace(starts ...Beam) BeamReport {
+	width := lab.width()
+	report := BeamReport{Energized: make(map[Position]bool), Splitters: make(map[Position]bool)}
+
+	// depth first, so that a beam met again while its descendants are still on the stack is a loop,
+	// while one met again after it has been fully traced is only two beams merging
+	const (
+		onStack = 1
+		done    = 2
+	)
+	state := make(map[Beam]int)
+	type frame struct {
+		beam Beam
+		next []Beam
+	}
+
+	for _, start := range starts {
+		if !lab.inside(start.Pos, width) || state[start] != 0 {
+			continue
+		}
+		stack := []frame{}
+		push := func(b Beam) {
+			state[b] = onStack
+			report.Energized[b.Pos] = true
+			if lab.at(b.Pos) == splitterChar && b.Dir.vertical() {
+				report.Splitters[b.Pos] = true
+			}
+			next, exits := lab.step(b, width)
+			report.Exits = append(report.Exits, exits...)
+			stack = append(stack, frame{beam: b, next: next})
+		}
+		push(start)
+		for len(stack) > 0 {
+			top := &stack[len(stack)-1]
+			if len(top.next) == 0 {
+				state[top.beam] = done
+				stack = stack[:len(stack)-1]
+				continue
+			}
+			b := top.next[0]
+			top.next = top.next[1:]
+			switch state[b] {
+			case 0:
+				push(b)
+			case onStack:
+				report.Loops = append(report.Loops, b)
+			}
+		}
+	}
+
+	sort.Slice(report.Exits, func(i, j int) bool {
+		a, b := report.Exits[i], report.Exits[j]
+		if a.Pos != b.Pos {
+			if a.Pos.row != b.Pos.row {
+				return a.Pos.row < b.Pos.row
+			}
+			return a.Pos.col < b.Pos.col
+		}
+		return a.Dir < b.Dir
+	})
+	report.Exits = dedupExits(report.Exits)
+	return report
+}
+
+// dedupExits drops repeated exits from a sorted list
+func dedupExits(exits []Exit) []Exit {
+	out := exits[:0]
+	for _, e := range exits {
+		if len(out) == 0 || e != out[len(out)-1] {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// RenderEnergized draws the lab with every energized cell that holds no element shown as '|'.
+func (lab *Lab) RenderEnergized(report BeamReport) string {
+	var sb strings.Builder
+	for row, line := range lab.Rows {
+		for col, char := range line {
+			if char == emptyChar && report.Energized[Position{row: row, col: col}] {
+				char = '|'
+			}
+			sb.WriteByte(char)
+		}
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTraceExample(t *testing.T) {
+	lab := NewLab(exampleLab)
+	start, err := lab.FindStart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := lab.Trace(Beam{Pos: start, Dir: Down})
+	if len(report.Splitters) != 21 {
+		t.Errorf("splitters hit = %d; want 21", len(report.Splitters))
+	}
+	if len(report.Exits) != 9 || len(report.Loops) != 0 {
+		t.Errorf("exits = %v, loops = %v; want 9 exits and no loop", report.Exits, report.Loops)
+	}
+}
+
+func TestTraceElements(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		energized int
+		exits     []Exit
+		loops     []Beam
+	}{
+		{
+			name:      "absorber",
+			input:     "S\n#\n.\n",
+			energized: 2,
+		},
+		{
+			name:      "splitter at the edge",
+			input:     "S.\n^.\n",
+			energized: 3,
+			exits:     []Exit{{Pos: Position{1, 0}, Dir: Left}, {Pos: Position{1, 1}, Dir: Down}},
+		},
+		{
+			name:      "mirror and splitter met side on",
+			input:     "S..\n\\^.\n",
+			energized: 4,
+			exits:     []Exit{{Pos: Position{1, 2}, Dir: Right}},
+		},
+		{
+			name:      "mergers",
+			input:     "..S..\n..^..\n.>v<.\n.....\n",
+			energized: 8,
+			exits:     []Exit{{Pos: Position{3, 2}, Dir: Down}},
+		},
+		{
+			name:      "loop",
+			input:     ".S..\n.>.\\\n....\n.\\./\n",
+			energized: 9,
+			loops:     []Beam{{Pos: Position{1, 2}, Dir: Right}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lab := NewLab(tt.input)
+			start, err := lab.FindStart()
+			if err != nil {
+				t.Fatal(err)
+			}
+			report := lab.Trace(Beam{Pos: start, Dir: Down})
+			if len(report.Energized) != tt.energized {
+				t.Errorf("energized = %d; want %d", len(report.Energized), tt.energized)
+			}
+			if len(report.Exits) != len(tt.exits) || (len(tt.exits) > 0 && !reflect.DeepEqual(report.Exits, tt.exits)) {
+				t.Errorf("exits = %v; want %v", report.Exits, tt.exits)
+			}
+			if !reflect.DeepEqual(report.Loops, tt.loops) {
+				t.Errorf("loops = %v; want %v", report.Loops, tt.loops)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// SplitterOdds gives, for every splitter, the probability that it sends a beam to its left.
+// The beam goes right otherwise.
+type SplitterOdds struct {
+	Left      *big.Rat              // for every splitter without an override
+	Overrides map[Position]*big.Rat // per splitter
+}
+
+// NewSplitterOdds returns odds where every splitter sends beams left with probability left.
+func NewSplitterOdds(left *big.Rat) SplitterOdds {
+	return SplitterOdds{Left: left, Overrides: make(map[Position]*big.Rat)}
+}
+
+func (o SplitterOdds) leftAt(pos Position) *big.Rat {
+	if p, ok := o.Overrides[pos]; ok {
+		return p
+	}
+	return o.Left
+}
+
+var ratOne = big.NewRat(1, 1)
+
+func checkProbability(p *big.Rat) error {
+	if p == nil || p.Sign() < 0 || p.Cmp(ratOne) > 0 {
+		return fmt.Errorf("probability %v is not between 0 and 1", p)
+	}
+	return nil
+}
+
+func (o SplitterOdds) validate() error {
+	if err := checkProbability(o.Left); err != nil {
+		return err
+	}
+	for pos, p := range o.Overrides {
+		if err := checkProbability(p); err != nil {
+			return fmt.Errorf("splitter at (%d,%d): %w", pos.row, pos.col, err)
+		}
+	}
+	return nil
+}
+
+// ParseOverride reads a per splitter override written as "row,col=p", p being a fraction
+// such as 1/3 or a decimal such as 0.25.
+func (o SplitterOdds) ParseOverride(s string) error {
+	where, value, ok := strings.Cut(s, "=")
+	if !ok {
+		return fmt.Errorf("override %q is not row,col=p", s)
+	}
+	rowText, colText, ok := strings.Cut(where, ",")
+	if !ok {
+		return fmt.Errorf("override %q is not row,col=p", s)
+	}
+	row, err := strconv.Atoi(strings.TrimSpace(rowText))
+	if err != nil {
+		return fmt.Errorf("override %q: %w", s, err)
+	}
+	col, err := strconv.Atoi(strings.TrimSpace(colText))
+	if err != nil {
+		return fmt.Errorf("override %q: %w", s, err)
+	}
+	p, ok := new(big.Rat).SetString(strings.TrimSpace(value))
+	if !ok {
+		return fmt.Errorf("override %q: invalid probability %q", s, value)
+	}
+	if err := checkProbability(p); err != nil {
+		return fmt.Errorf("override %q: %w", s, err)
+	}
+	o.Overrides[Position{row: row, col: col}] = p
+	return nil
+}
+
+// ExitDistribution is where a single beam ends up when every splitter picks a side at random.
+type ExitDistribution struct {
+	Exits             []*big.Rat            // probability of leaving through the bottom of each column
+	OffSide           *big.Rat              // probability of leaving through the left or right edge
+	Reach             map[Position]*big.Rat // probability of reaching each splitter that can be reached
+	ExpectedSplitters *big.Rat              // expected number of splitters the beam goes through
+}
+
+// SweepProbabilities follows a single beam from the given position, splitting it the way
+// SweepTimelines does but carrying, instead of a number of timelines, the probability of the
+// beam being in each column. Rational arithmetic keeps the results exact.
+//
+// A beam goes through every splitter at most once, as it only moves down, so the expected
+// number of splitters it visits is the sum of the probabilities of reaching each of them.
+func SweepProbabilities(lab *Lab, pos Position, odds SplitterOdds) (ExitDistribution, error) {
+	if err := odds.validate(); err != nil {
+		return ExitDistribution{}, err
+	}
+
+	width := lab.width()
+	dist := ExitDistribution{
+		OffSide:           new(big.Rat),
+		Reach:             make(map[Position]*big.Rat),
+		ExpectedSplitters: new(big.Rat),
+	}
+	newRow := func() []*big.Rat {
+		row := make([]*big.Rat, width)
+		for col := range row {
+			row[col] = new(big.Rat)
+		}
+		return row
+	}
+
+	if pos.col < 0 || pos.col >= width {
+		return ExitDistribution{}, fmt.Errorf("position (%d,%d) is outside the lab", pos.row, pos.col)
+	}
+	probs := newRow()
+	probs[pos.col].SetInt64(1)
+
+	for row := max(pos.row, 0); row < len(lab.Rows); row++ {
+		line := lab.Rows[row]
+		next := newRow()
+		for col, p := range probs {
+			if p.Sign() == 0 {
+				continue
+			}
+			if col >= len(line) || line[col] != splitterChar {
+				next[col].Add(next[col], p)
+				continue
+			}
+			splitter := Position{row: row, col: col}
+			dist.Reach[splitter] = p
+			dist.ExpectedSplitters.Add(dist.ExpectedSplitters, p)
+
+			left := new(big.Rat).Mul(p, odds.leftAt(splitter))
+			right := new(big.Rat).Sub(p, left)
+			if col > 0 {
+				next[col-1].Add(next[col-1], left)
+			} else {
+				dist.OffSide.Add(dist.OffSide, left)
+			}
+			if col < len(line)-1 {
+				next[col+1].Add(next[col+1], right)
+			} else {
+				dist.OffSide.Add(dist.OffSide, right)
+			}
+		}
+		probs = next
+	}
+
+	dist.Exits = probs
+	return dist, nil
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"math/big"
+	"math/rand"
+	"testing"
+)
+
+// referenceDistribution walks every path of the beam, multiplying the probabilities along the way
+func referenceDistribution(lab *Lab, pos Position, odds SplitterOdds, p *big.Rat, exits map[int]*big.Rat, visited *big.Rat) {
+	row, col := pos.row, pos.col
+	for row < len(lab.Rows) && lab.Rows[row][col] != splitterChar {
+		row++
+	}
+	if row == len(lab.Rows) {
+		if exits[col] == nil {
+			exits[col] = new(big.Rat)
+		}
+		exits[col].Add(exits[col], p)
+		return
+	}
+	visited.Add(visited, p)
+	left := new(big.Rat).Mul(p, odds.leftAt(Position{row: row, col: col}))
+	right := new(big.Rat).Sub(p, left)
+	if col > 0 {
+		referenceDistribution(lab, Position{row: row + 1, col: col - 1}, odds, left, exits, visited)
+	}
+	if col < len(lab.Rows[row])-1 {
+		referenceDistribution(lab, Position{row: row + 1, col: col + 1}, odds, right, exits, visited)
+	}
+}
+
+func TestSweepProbabilitiesExample(t *testing.T) {
+	lab := NewLab(exampleLab)
+	start, _ := lab.FindStart()
+	dist, err := SweepProbabilities(&lab, start, NewSplitterOdds(big.NewRat(1, 2)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	total := new(big.Rat).Set(dist.OffSide)
+	for _, p := range dist.Exits {
+		total.Add(total, p)
+	}
+	if total.Cmp(ratOne) != 0 {
+		t.Errorf("probabilities add up to %s; want 1", total)
+	}
+	if want := big.NewRat(17, 32); dist.Exits[8].Cmp(want) != 0 {
+		t.Errorf("P(column 8) = %s; want %s", dist.Exits[8], want)
+	}
+	if want := big.NewRat(295, 64); dist.ExpectedSplitters.Cmp(want) != 0 {
+		t.Errorf("ExpectedSplitters = %s; want %s", dist.ExpectedSplitters, want)
+	}
+	if len(dist.Reach) != 21 {
+		t.Errorf("%d splitters can be reached; want 21", len(dist.Reach))
+	}
+
+	// a splitter that always sends the beam the same way makes the whole lab deterministic
+	odds := NewSplitterOdds(ratOne)
+	if err := odds.ParseOverride("2,7=0"); err != nil {
+		t.Fatal(err)
+	}
+	dist, err = SweepProbabilities(&lab, start, odds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dist.Exits[4].Cmp(ratOne) != 0 || dist.ExpectedSplitters.Cmp(big.NewRat(5, 1)) != 0 {
+		t.Errorf("P(column 4) = %s, ExpectedSplitters = %s; want 1 and 5", dist.Exits[4], dist.ExpectedSplitters)
+	}
+}
+
+func TestSweepProbabilitiesMatchesPaths(t *testing.T) {
+	rng := rand.New(rand.NewSource(47))
+	for i := 0; i < 100; i++ {
+		lab := randomLab(rng, 2+rng.Intn(12), 1+rng.Intn(9))
+		start, _ := lab.FindStart()
+		odds := NewSplitterOdds(big.NewRat(int64(rng.Intn(5)), 4))
+		for row := range lab.Rows {
+			for col := range lab.Rows[row] {
+				if lab.Rows[row][col] == splitterChar && rng.Intn(4) == 0 {
+					odds.Overrides[Position{row: row, col: col}] = big.NewRat(int64(rng.Intn(4)), 3)
+				}
+			}
+		}
+
+		dist, err := SweepProbabilities(&lab, start, odds)
+		if err != nil {
+			t.Fatal(err)
+		}
+		exits := make(map[int]*big.Rat)
+		visited := new(big.Rat)
+		referenceDistribution(&lab, start, odds, big.NewRat(1, 1), exits, visited)
+
+		for col, p := range dist.Exits {
+			want := exits[col]
+			if want == nil {
+				want = new(big.Rat)
+			}
+			if p.Cmp(want) != 0 {
+				t.Fatalf("P(column %d) = %s; want %s on\n%s", col, p, want, lab.Rows)
+			}
+		}
+		if dist.ExpectedSplitters.Cmp(visited) != 0 {
+			t.Fatalf("ExpectedSplitters = %s; want %s on\n%s", dist.ExpectedSplitters, visited, lab.Rows)
+		}
+	}
+}
+
+func TestSplitterOddsErrors(t *testing.T) {
+	lab := NewLab(exampleLab)
+	start, _ := lab.FindStart()
+	if _, err := SweepProbabilities(&lab, start, NewSplitterOdds(big.NewRat(3, 2))); err == nil {
+		t.Error("a probability above 1 should be rejected")
+	}
+
+	odds := NewSplitterOdds(big.NewRat(1, 2))
+	for _, override := range []string{"2,7", "2=1/2", "x,7=1/2", "2,7=half", "2,7=-1/2"} {
+		if err := odds.ParseOverride(override); err == nil {
+			t.Errorf("ParseOverride(%q) should fail", override)
+		}
+	}
+	if err := odds.ParseOverride("2, 7 = 0.25"); err != nil {
+		t.Fatal(err)
+	}
+	if p := odds.leftAt(Position{row: 2, col: 7}); p.Cmp(big.NewRat(1, 4)) != 0 {
+		t.Errorf("override = %s; want 1/4", p)
+	}
+}
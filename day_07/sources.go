@@ -0,0 +1,66 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var errNoSource = errors.New("the lab has no beam source")
+
+// FindSources returns every source of the lab, top to bottom and left to right.
+func (lab *Lab) FindSources() []Position {
+	sources := make([]Position, 0)
+	for row, line := range lab.Rows {
+		for col, char := range line {
+			if char == startChar {
+				sources = append(sources, Position{row: row, col: col})
+			}
+		}
+	}
+	return sources
+}
+
+// Sources returns the sources of the lab followed by the extra ones, which must lie inside
+// the lab. A position given more than once, or an extra source on an 'S', is kept once.
+// It is an error for there to be no source at all.
+func (lab *Lab) Sources(extra []Position) ([]Position, error) {
+	width := lab.width()
+	sources := lab.FindSources()
+	seen := make(map[Position]bool)
+	for _, pos := range sources {
+		seen[pos] = true
+	}
+	for _, pos := range extra {
+		if !lab.inside(pos, width) {
+			return nil, fmt.Errorf("source (%d,%d) is outside the lab", pos.row, pos.col)
+		}
+		if seen[pos] {
+			continue
+		}
+		seen[pos] = true
+		sources = append(sources, pos)
+	}
+	if len(sources) == 0 {
+		return nil, errNoSource
+	}
+	return sources, nil
+}
+
+// ParseSource reads a source position written as "row,col".
+func ParseSource(s string) (Position, error) {
+	rowText, colText, ok := strings.Cut(s, ",")
+	if !ok {
+		return Position{}, fmt.Errorf("source %q is not row,col", s)
+	}
+	row, err := strconv.Atoi(strings.TrimSpace(rowText))
+	if err != nil {
+		return Position{}, fmt.Errorf("source %q: %w", s, err)
+	}
+	col, err := strconv.Atoi(strings.TrimSpace(colText))
+	if err != nil {
+		return Position{}, fmt.Errorf("source %q: %w", s, err)
+	}
+	return Position{row: row, col: col}, nil
+}
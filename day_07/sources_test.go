@@ -0,0 +1,120 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+const twoSourceLab = `..S....
+..^....
+.^.^.S.
+.....^.
+.......
+`
+
+func TestFindSources(t *testing.T) {
+	lab := NewLab(twoSourceLab)
+	expected := []Position{{0, 2}, {2, 5}}
+	if got := lab.FindSources(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("FindSources() = %v; want %v", got, expected)
+	}
+
+	sources, err := lab.Sources([]Position{{4, 0}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := append(expected, Position{4, 0}); !reflect.DeepEqual(sources, expected) {
+		t.Errorf("Sources() = %v; want %v", sources, expected)
+	}
+	if _, err := lab.Sources([]Position{{5, 0}}); err == nil {
+		t.Error("a source below the lab should be rejected")
+	}
+
+	empty := NewLab("...\n.^.\n")
+	if _, err := empty.Sources(nil); !errors.Is(err, errNoSource) {
+		t.Errorf("Sources() error = %v; want %v", err, errNoSource)
+	}
+	if _, err := empty.FindStart(); !errors.Is(err, errNoSource) {
+		t.Errorf("FindStart() error = %v; want %v", err, errNoSource)
+	}
+	if sources, err := empty.Sources([]Position{{0, 1}}); err != nil || len(sources) != 1 {
+		t.Errorf("Sources() with an injected source = %v, %v", sources, err)
+	}
+}
+
+func TestParseSource(t *testing.T) {
+	if pos, err := ParseSource("3, 14"); err != nil || pos != (Position{3, 14}) {
+		t.Errorf("ParseSource() = %v, %v; want (3,14)", pos, err)
+	}
+	for _, s := range []string{"3", "a,1", "1,b", ""} {
+		if _, err := ParseSource(s); err == nil {
+			t.Errorf("ParseSource(%q) should fail", s)
+		}
+	}
+}
+
+func TestMultipleSources(t *testing.T) {
+	lab := NewLab(twoSourceLab)
+	sources := lab.FindSources()
+
+	// the beam from the first source hits (1,2), (2,1) and (2,3), the second one (3,5)
+	if n := CountSplitters(&lab, sources[0]); n != 3 {
+		t.Errorf("CountSplitters(first) = %d; want 3", n)
+	}
+	if n := CountSplitters(&lab, sources[1]); n != 1 {
+		t.Errorf("CountSplitters(second) = %d; want 1", n)
+	}
+	if n := CountSplitters(&lab, sources...); n != 4 {
+		t.Errorf("CountSplitters(both) = %d; want 4", n)
+	}
+
+	first := CountPaths(&lab, sources[0])
+	second := CountPaths(&lab, sources[1])
+	if first.Int64() != 4 || second.Int64() != 2 {
+		t.Errorf("CountPaths() = %s and %s; want 4 and 2", first, second)
+	}
+	sweep := SweepTimelines(&lab, sources...)
+	if sweep.Total.Int64() != 6 {
+		t.Errorf("combined timelines = %s; want 6", sweep.Total)
+	}
+	if hits := sweep.SplitterHits[Position{3, 5}]; hits == nil || hits.Int64() != 1 {
+		t.Errorf("SplitterHits[(3,5)] = %v; want 1", hits)
+	}
+
+	report := lab.Trace(Beam{Pos: sources[0], Dir: Down}, Beam{Pos: sources[1], Dir: Down})
+	if len(report.Splitters) != 4 {
+		t.Errorf("Trace() hit %d splitters; want 4", len(report.Splitters))
+	}
+}
+
+func TestSourceOnSplitter(t *testing.T) {
+	lab := NewLab("S....\n.....\n..^..\n.....\n")
+	sources, err := lab.Sources([]Position{{2, 2}, {2, 2}, {0, 0}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []Position{{0, 0}, {2, 2}}; !reflect.DeepEqual(sources, expected) {
+		t.Fatalf("Sources() = %v; want %v", sources, expected)
+	}
+
+	// the injected source on the splitter is split at once by every way of following the beams
+	onSplitter := sources[1]
+	if n := CountSplitters(&lab, onSplitter); n != 1 {
+		t.Errorf("CountSplitters() = %d; want 1", n)
+	}
+	if n := CountPaths(&lab, onSplitter); n.Int64() != 2 {
+		t.Errorf("CountPaths() = %s; want 2", n)
+	}
+	if report := lab.Trace(Beam{Pos: onSplitter, Dir: Down}); len(report.Splitters) != 1 {
+		t.Errorf("Trace() hit %d splitters; want 1", len(report.Splitters))
+	}
+
+	// both sources together: the 'S' misses the splitter, each source counted once
+	if n := CountSplitters(&lab, sources...); n != 1 {
+		t.Errorf("CountSplitters(both) = %d; want 1", n)
+	}
+	if n := SweepTimelines(&lab, sources...).Total; n.Int64() != 3 {
+		t.Errorf("SweepTimelines(both).Total = %s; want 3", n)
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+)
+
+const ansiReset = "\x1b[0m"
+
+// TimelineSweep is what a single pass down the lab learns about the timelines from one position.
+type TimelineSweep struct {
+	Density      [][]*big.Int          // timelines entering each cell, row by row
+	SplitterHits map[Position]*big.Int // timelines reaching each splitter that is hit at all
+	Exits        []*big.Int            // timelines leaving through the bottom of each column
+	Total        *big.Int              // all the timelines reaching the bottom
+}
+
+// SweepTimelines follows the timelines from the given sources to the bottom of the lab. Every
+// source starts one timeline, when the sweep reaches its row, so the counts add up over sources.
+//
+// It sweeps the lab row by row, carrying the number of timelines in each column: a column
+// that reaches a splitter hands its count to the columns on its left and right, which carry
+// on downward from the next row. Timelines double at every row of splitters, so counts are big.Ints.
+func SweepTimelines(lab *Lab, sources ...Position) TimelineSweep {
+	width := lab.width()
+	sweep := TimelineSweep{
+		Density:      make([][]*big.Int, len(lab.Rows)),
+		SplitterHits: make(map[Position]*big.Int),
+		Total:        new(big.Int),
+	}
+	newRow := func() []*big.Int {
+		row := make([]*big.Int, width)
+		for col := range row {
+			row[col] = new(big.Int)
+		}
+		return row
+	}
+
+	counts := newRow()
+	for row := range lab.Rows {
+		for _, source := range sources {
+			if source.row == row && source.col >= 0 && source.col < width {
+				counts[source.col].Add(counts[source.col], big.NewInt(1))
+			}
+		}
+		sweep.Density[row] = counts
+
+		line := lab.Rows[row]
+		next := newRow()
+		for col, n := range counts {
+			if n.Sign() == 0 {
+				continue
+			}
+			if col >= len(line) || line[col] != splitterChar {
+				next[col].Add(next[col], n)
+				continue
+			}
+			sweep.SplitterHits[Position{row: row, col: col}] = n
+			if col > 0 {
+				next[col-1].Add(next[col-1], n)
+			}
+			if col < len(line)-1 {
+				next[col+1].Add(next[col+1], n)
+			}
+		}
+		counts = next
+	}
+
+	sweep.Exits = counts
+	for _, n := range counts {
+		sweep.Total.Add(sweep.Total, n)
+	}
+	return sweep
+}
+
+// SplittersHit returns the splitters reached by at least one timeline, top to bottom and left to right.
+func (s TimelineSweep) SplittersHit() []Position {
+	positions := make([]Position, 0, len(s.SplitterHits))
+	for pos := range s.SplitterHits {
+		positions = append(positions, pos)
+	}
+	sort.Slice(positions, func(i, j int) bool {
+		if positions[i].row != positions[j].row {
+			return positions[i].row < positions[j].row
+		}
+		return positions[i].col < positions[j].col
+	})
+	return positions
+}
+
+// from dark blue for a single timeline to red for the busiest cells, in the 256 color palette
+var heatmapColors = []int{19, 21, 27, 33, 39, 45, 50, 48, 82, 118, 154, 190, 226, 220, 214, 208, 202, 196}
+
+// heatLevel maps a timeline count onto heatmapColors, on a log scale: the counts grow
+// exponentially down the lab, so a linear scale would leave all but the last rows dark.
+func heatLevel(n *big.Int, maxBits int) int {
+	if maxBits <= 1 {
+		return len(heatmapColors) - 1
+	}
+	return (n.BitLen() - 1) * (len(heatmapColors) - 1) / (maxBits - 1)
+}
+
+// Heatmap draws the lab with every cell a timeline goes through colored by how many do, using ANSI colors.
+func (s TimelineSweep) Heatmap(lab *Lab) string {
+	maxBits := 0
+	for _, row := range s.Density {
+		for _, n := range row {
+			maxBits = max(maxBits, n.BitLen())
+		}
+	}
+
+	var sb strings.Builder
+	for row, line := range lab.Rows {
+		for col, n := range s.Density[row] {
+			char := emptyChar
+			if col < len(line) {
+				char = line[col]
+			}
+			if n.Sign() == 0 {
+				sb.WriteByte(char)
+				continue
+			}
+			if char == emptyChar {
+				char = '|'
+			}
+			fmt.Fprintf(&sb, "\x1b[38;5;%dm%c%s", heatmapColors[heatLevel(n, maxBits)], char, ansiReset)
+		}
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"container/heap"
+	"sort"
+)
+
+// nodeOrder sorts the neighbors of a node by distance, then by id
+func nodeOrder(from Node, candidates []Node) {
+	sort.Slice(candidates, func(i, j int) bool {
+		di, dj := Distance(from, candidates[i]), Distance(from, candidates[j])
+		if di != dj {
+			return di < dj
+		}
+		return candidates[i].id < candidates[j].id
+	})
+}
+
+// candidateList holds, for one node, its connections to nodes with a larger id, closest first.
+// Only a prefix of them is known at any time; it is fetched again, twice as long, once used up.
+type candidateList struct {
+	from    Node
+	fetched int            // how many neighbors were asked from the tree
+	pairs   []NodeDistance // the known candidates
+	next    int            // the number of candidates already handed out
+}
+
+type candidateHeap []*candidateList
+
+func (h candidateHeap) Len() int { return len(h) }
+func (h candidateHeap) Less(i, j int) bool {
+	return h[i].pairs[h[i].next].less(h[j].pairs[h[j].next])
+}
+func (h candidateHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *candidateHeap) Push(x any)   { *h = append(*h, x.(*candidateList)) }
+func (h *candidateHeap) Pop() any {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
+
+// EdgeGenerator hands out the node distances of all pairs in ascending order without ever
+// computing all of them: a heap holds the next closest pair of every node, and the pairs of a
+// node are found with a KDTree, a few at a time, when they are needed.
+type EdgeGenerator struct {
+	tree *KDTree
+	heap candidateHeap
+}
+
+// initial number of neighbors fetched per node
+const initialCandidates = 4
+
+func NewEdgeGenerator(nodes []Node) *EdgeGenerator {
+	gen := &EdgeGenerator{tree: NewKDTree(nodes)}
+	for _, node := range nodes {
+		list := &candidateList{from: node}
+		if gen.refill(list) {
+			gen.heap = append(gen.heap, list)
+		}
+	}
+	heap.Init(&gen.heap)
+	return gen
+}
+
+// refill makes sure the list has a candidate left, fetching more neighbors if needed.
+// It returns false once the node has no pairs left.
+func (gen *EdgeGenerator) refill(list *candidateList) bool {
+	total := gen.tree.Len() - 1
+	for list.next >= len(list.pairs) {
+		if list.fetched >= total {
+			return false
+		}
+		list.fetched = min(total, max(initialCandidates, 2*list.fetched))
+		// a longer fetch starts with the same neighbors, so the pairs already handed out stay a prefix
+		list.pairs = list.pairs[:0]
+		for _, neighbor := range gen.tree.KNearest(list.from, list.fetched) {
+			if neighbor.id > list.from.id {
+				list.pairs = append(list.pairs, newNodeDistance(list.from, neighbor))
+			}
+		}
+	}
+	return true
+}
+
+// Next returns the closest pair not returned yet, or false when every pair has been.
+func (gen *EdgeGenerator) Next() (NodeDistance, bool) {
+	if len(gen.heap) == 0 {
+		return NodeDistance{}, false
+	}
+	list := gen.heap[0]
+	nd := list.pairs[list.next]
+	list.next++
+	if gen.refill(list) {
+		heap.Fix(&gen.heap, 0)
+	} else {
+		heap.Pop(&gen.heap)
+	}
+	return nd, true
+}
+
+// ClosestPairs returns the n closest pairs of nodes, closest first.
+func ClosestPairs(nodes []Node, n int) []NodeDistance {
+	gen := NewEdgeGenerator(nodes)
+	pairs := make([]NodeDistance, 0, n)
+	for len(pairs) < n {
+		nd, ok := gen.Next()
+		if !ok {
+			break
+		}
+		pairs = append(pairs, nd)
+	}
+	return pairs
+}
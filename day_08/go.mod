@@ -1,5 +1,3 @@
 module github.com/shaohong/aoc2025/day08
 
 go 1.22.1
-
-require github.com/shaohong/aoc2025/commons v0.0.0-20251214131808-fc170afa8345
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"container/heap"
+	"math"
+	"sort"
+)
+
+// KDTree is a k-d tree over the nodes, kept implicitly in a slice: the node in the middle of
+// any range splits the rest of the range on one axis, x, y and z in turn as the ranges shrink.
+type KDTree struct {
+	nodes []Node
+}
+
+func axisCoord(n Node, axis int) int {
+	switch axis {
+	case 0:
+		return n.x
+	case 1:
+		return n.y
+	default:
+		return n.z
+	}
+}
+
+// NewKDTree builds the tree on a copy of the nodes.
+func NewKDTree(nodes []Node) *KDTree {
+	t := &KDTree{nodes: append([]Node(nil), nodes...)}
+	t.build(0, len(t.nodes), 0)
+	return t
+}
+
+func (t *KDTree) build(lo, hi, depth int) {
+	if hi-lo <= 1 {
+		return
+	}
+	axis := depth % 3
+	part := t.nodes[lo:hi]
+	sort.Slice(part, func(i, j int) bool {
+		return axisCoord(part[i], axis) < axisCoord(part[j], axis)
+	})
+	mid := (lo + hi) / 2
+	t.build(lo, mid, depth+1)
+	t.build(mid+1, hi, depth+1)
+}
+
+// Len returns the number of nodes in the tree.
+func (t *KDTree) Len() int {
+	return len(t.nodes)
+}
+
+// closer orders the neighbors of a node by distance, then by id
+func closer(a, b Node, distA, distB int) bool {
+	if distA != distB {
+		return distA < distB
+	}
+	return a.id < b.id
+}
+
+// neighborHeap keeps the k closest nodes found so far, the furthest of them on top
+type neighborHeap struct {
+	nodes []Node
+	dists []int
+}
+
+func (h *neighborHeap) Len() int { return len(h.nodes) }
+func (h *neighborHeap) Less(i, j int) bool {
+	return closer(h.nodes[j], h.nodes[i], h.dists[j], h.dists[i])
+}
+func (h *neighborHeap) Swap(i, j int) {
+	h.nodes[i], h.nodes[j] = h.nodes[j], h.nodes[i]
+	h.dists[i], h.dists[j] = h.dists[j], h.dists[i]
+}
+func (h *neighborHeap) Push(x any) {
+	nd := x.(nodeWithDistance)
+	h.nodes = append(h.nodes, nd.node)
+	h.dists = append(h.dists, nd.dist)
+}
+func (h *neighborHeap) Pop() any {
+	n := len(h.nodes) - 1
+	nd := nodeWithDistance{node: h.nodes[n], dist: h.dists[n]}
+	h.nodes, h.dists = h.nodes[:n], h.dists[:n]
+	return nd
+}
+
+type nodeWithDistance struct {
+	node Node
+	dist int
+}
+
+func (h *neighborHeap) offer(node Node, dist, k int) {
+	if h.Len() < k {
+		heap.Push(h, nodeWithDistance{node: node, dist: dist})
+		return
+	}
+	if closer(node, h.nodes[0], dist, h.dists[0]) {
+		h.nodes[0], h.dists[0] = node, dist
+		heap.Fix(h, 0)
+	}
+}
+
+// KNearest returns the k nodes closest to the given one, itself excluded, closest first.
+// Nodes at the same distance come in id order, so asking for more neighbors only ever
+// extends the list, which the EdgeGenerator relies on.
+func (t *KDTree) KNearest(from Node, k int) []Node {
+	k = min(k, len(t.nodes))
+	if k <= 0 {
+		return nil
+	}
+	h := &neighborHeap{}
+	t.nearest(0, len(t.nodes), 0, from, k, h)
+
+	out := make([]Node, h.Len())
+	for i := len(out) - 1; i >= 0; i-- {
+		out[i] = heap.Pop(h).(nodeWithDistance).node
+	}
+	return out
+}
+
+func (t *KDTree) nearest(lo, hi, depth int, from Node, k int, h *neighborHeap) {
+	if lo >= hi {
+		return
+	}
+	mid := (lo + hi) / 2
+	node := t.nodes[mid]
+	if node.id != from.id {
+		h.offer(node, Distance(from, node), k)
+	}
+
+	axis := depth % 3
+	diff := axisCoord(from, axis) - axisCoord(node, axis)
+	nearLo, nearHi, farLo, farHi := lo, mid, mid+1, hi
+	if diff > 0 {
+		nearLo, nearHi, farLo, farHi = mid+1, hi, lo, mid
+	}
+	t.nearest(nearLo, nearHi, depth+1, from, k, h)
+	// the far side can only hold a closer node, or one as close with a smaller id, if the
+	// splitting plane is no further than the furthest neighbor kept
+	if h.Len() < k || diff*diff <= h.dists[0] {
+		t.nearest(farLo, farHi, depth+1, from, k, h)
+	}
+}
+
+// the largest radius whose square fits in an int
+var maxRadius = int(math.Sqrt(math.MaxInt))
+
+// WithinRadius returns the nodes at most radius away from the given one, itself excluded,
+// closest first and in id order at the same distance.
+func (t *KDTree) WithinRadius(from Node, radius int) []Node {
+	found := make([]Node, 0)
+	if radius < 0 {
+		return found
+	}
+	// past maxRadius the square overflows, and every distance that fits in an int is within it
+	maxDist := math.MaxInt
+	if radius <= maxRadius {
+		maxDist = radius * radius
+	}
+	t.within(0, len(t.nodes), 0, from, maxDist, &found)
+	nodeOrder(from, found)
+	return found
+}
+
+func (t *KDTree) within(lo, hi, depth int, from Node, maxDist int, found *[]Node) {
+	if lo >= hi {
+		return
+	}
+	mid := (lo + hi) / 2
+	node := t.nodes[mid]
+	if node.id != from.id && Distance(from, node) <= maxDist {
+		*found = append(*found, node)
+	}
+
+	axis := depth % 3
+	diff := axisCoord(from, axis) - axisCoord(node, axis)
+	if diff <= 0 || diff*diff <= maxDist {
+		t.within(lo, mid, depth+1, from, maxDist, found)
+	}
+	if diff >= 0 || diff*diff <= maxDist {
+		t.within(mid+1, hi, depth+1, from, maxDist, found)
+	}
+}
+
+// ClosestPair returns the two nodes closest to each other, the pair with the smallest ids
+// when several are equally close, or false when there are fewer than two nodes.
+func (t *KDTree) ClosestPair() (NodeDistance, bool) {
+	var best NodeDistance
+	found := false
+	for _, node := range t.nodes {
+		t.closest(0, len(t.nodes), 0, node, &best, &found)
+	}
+	return best, found
+}
+
+// closest searches the tree for a node that makes a closer pair with from than best. The
+// best pair so far is shared by all the searches, so that later ones prune most of the tree.
+func (t *KDTree) closest(lo, hi, depth int, from Node, best *NodeDistance, found *bool) {
+	if lo >= hi {
+		return
+	}
+	mid := (lo + hi) / 2
+	node := t.nodes[mid]
+	if node.id != from.id {
+		if nd := newNodeDistance(from, node); !*found || nd.less(*best) {
+			*best, *found = nd, true
+		}
+	}
+
+	axis := depth % 3
+	diff := axisCoord(from, axis) - axisCoord(node, axis)
+	nearLo, nearHi, farLo, farHi := lo, mid, mid+1, hi
+	if diff > 0 {
+		nearLo, nearHi, farLo, farHi = mid+1, hi, lo, mid
+	}
+	t.closest(nearLo, nearHi, depth+1, from, best, found)
+	if !*found || diff*diff <= best.distance {
+		t.closest(farLo, farHi, depth+1, from, best, found)
+	}
+}
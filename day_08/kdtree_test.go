@@ -0,0 +1,116 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// bruteNearest sorts all the other nodes by distance from the given one
+func bruteNearest(nodes []Node, from Node) []Node {
+	others := make([]Node, 0, len(nodes))
+	for _, node := range nodes {
+		if node.id != from.id {
+			others = append(others, node)
+		}
+	}
+	nodeOrder(from, others)
+	return others
+}
+
+func TestKDTreeKNearest(t *testing.T) {
+	rng := rand.New(rand.NewSource(50))
+	for _, spread := range []int{6, 1000} {
+		nodes := randomNodes(rng, 300, spread)
+		tree := NewKDTree(nodes)
+		if tree.Len() != len(nodes) {
+			t.Fatalf("Len() = %d; want %d", tree.Len(), len(nodes))
+		}
+		for _, from := range nodes[:30] {
+			others := bruteNearest(nodes, from)
+			for _, k := range []int{1, 3, 17, 299, 1000} {
+				want := others[:min(k, len(others))]
+				if got := tree.KNearest(from, k); !reflect.DeepEqual(got, want) {
+					t.Fatalf("KNearest(%+v, %d) = %v; want %v", from, k, got, want)
+				}
+			}
+		}
+
+		// a point that is not a node has every node as a neighbor
+		outside := Node{x: spread, y: -spread, z: 7, id: -1}
+		if got, want := tree.KNearest(outside, 5), bruteNearest(nodes, outside)[:5]; !reflect.DeepEqual(got, want) {
+			t.Errorf("KNearest(%+v, 5) = %v; want %v", outside, got, want)
+		}
+	}
+}
+
+func TestKDTreeWithinRadius(t *testing.T) {
+	rng := rand.New(rand.NewSource(51))
+	nodes := randomNodes(rng, 300, 40)
+	tree := NewKDTree(nodes)
+	for _, from := range nodes[:30] {
+		for _, radius := range []int{-1, 0, 3, 10, 25, 200} {
+			want := make([]Node, 0)
+			for _, node := range bruteNearest(nodes, from) {
+				if radius >= 0 && Distance(from, node) <= radius*radius {
+					want = append(want, node)
+				}
+			}
+			if got := tree.WithinRadius(from, radius); !reflect.DeepEqual(got, want) {
+				t.Fatalf("WithinRadius(%+v, %d) = %v; want %v", from, radius, got, want)
+			}
+		}
+	}
+}
+
+func TestKDTreeClosestPair(t *testing.T) {
+	rng := rand.New(rand.NewSource(52))
+	for _, n := range []int{2, 5, 50, 200} {
+		for _, spread := range []int{4, 100000} {
+			nodes := randomNodes(rng, n, spread)
+			got, ok := NewKDTree(nodes).ClosestPair()
+			if want := allPairs(nodes)[0]; !ok || got != want {
+				t.Fatalf("ClosestPair() = %+v; want %+v", got, want)
+			}
+		}
+	}
+	if _, ok := NewKDTree(ParseNodes("1,2,3\n")).ClosestPair(); ok {
+		t.Error("a single node has no closest pair")
+	}
+
+	nodes := ParseNodes(exampleNodes)
+	if got, ok := NewKDTree(nodes).ClosestPair(); !ok || got.nodePair != [2]int{0, 19} {
+		t.Errorf("ClosestPair() = %+v; want nodes 0 and 19", got)
+	}
+}
+
+func TestEdgeGeneratorMatchesAllPairs(t *testing.T) {
+	rng := rand.New(rand.NewSource(53))
+	nodes := randomNodes(rng, 120, 20)
+	want := allPairs(nodes)
+	gen := NewEdgeGenerator(nodes)
+	got := make([]NodeDistance, 0, len(want))
+	for {
+		nd, ok := gen.Next()
+		if !ok {
+			break
+		}
+		got = append(got, nd)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("edges differ from sorting all pairs")
+	}
+}
+
+func TestWithinHugeRadius(t *testing.T) {
+	rng := rand.New(rand.NewSource(54))
+	nodes := randomNodes(rng, 50, 1000)
+	tree := NewKDTree(nodes)
+	want := bruteNearest(nodes, nodes[0])
+	for _, radius := range []int{maxRadius, maxRadius + 1, math.MaxInt} {
+		if got := tree.WithinRadius(nodes[0], radius); !reflect.DeepEqual(got, want) {
+			t.Errorf("WithinRadius(%d) found %d nodes; want all %d", radius, len(got), len(want))
+		}
+	}
+}
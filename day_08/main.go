@@ -6,8 +6,6 @@ import (
 	"os"
 	"sort"
 	"strings"
-
-	commons "github.com/shaohong/aoc2025/commons"
 )
 
 type Node struct {
@@ -40,7 +38,12 @@ func ParseInput() []Node {
 	if err != nil {
 		panic(err)
 	}
-	lines := strings.Split(string(input), "\n")
+	return ParseNodes(string(input))
+}
+
+// ParseNodes reads one "x,y,z" node per line; a node's id is its line number, from 0.
+func ParseNodes(input string) []Node {
+	lines := strings.Split(input, "\n")
 	nodes := make([]Node, 0)
 	for n, line := range lines {
 		if line == "" {
@@ -56,31 +59,8 @@ func ParseInput() []Node {
 func Part1(connectionsToCheck int, topNCircuit int) {
 	nodes := ParseInput()
 
-	nodeDistances := make([]NodeDistance, 0)
-
-	for i, nodeA := range nodes {
-		for j := i + 1; j < len(nodes); j++ {
-			nodeB := nodes[j]
-			dist := Distance(nodeA, nodeB)
-			nodeDistances = append(nodeDistances, NodeDistance{
-				distance: dist,
-				nodePair: [2]int{nodeA.id, nodeB.id},
-			})
-		}
-	}
-
-	// sort the nodeDistances by distance
-	sort.Slice(nodeDistances, func(i, j int) bool {
-		return nodeDistances[i].distance < nodeDistances[j].distance
-	})
-
-	// put these node distances into a queue
-	nodeDistanceQueue := commons.Queue[NodeDistance]{}
-	for _, nd := range nodeDistances {
-		nodeDistanceQueue.Enqueue(nd)
-	}
-
-	circuits := makingConnections(nodeDistanceQueue, connectionsToCheck)
+	// only the pairs that will be connected are ever computed, not all n²/2 of them
+	circuits := makingConnections(ClosestPairs(nodes, connectionsToCheck))
 
 	fmt.Println("Total circuits formed:", len(circuits))
 
@@ -92,15 +72,12 @@ func Part1(connectionsToCheck int, topNCircuit int) {
 	fmt.Printf("Total product of largest %d circuits: %d\n", topNCircuit, totalProducts)
 }
 
-func makingConnections(nodeDistanceQueue commons.Queue[NodeDistance], connectionsToCheck int) Circuits {
+// makingConnections connects the given pairs in order and returns the circuits, largest first.
+func makingConnections(nodeDistances []NodeDistance) Circuits {
 	circuits := Circuits{}
 	totalConnectionsMade := 0
 
-	for i := 0; i < connectionsToCheck; i++ {
-		smalledDistanceNodePair, ok := nodeDistanceQueue.Dequeue()
-		if !ok {
-			break
-		}
+	for _, smalledDistanceNodePair := range nodeDistances {
 		nodeAID := smalledDistanceNodePair.nodePair[0]
 		nodeBID := smalledDistanceNodePair.nodePair[1]
 
@@ -121,7 +98,6 @@ func makingConnections(nodeDistanceQueue commons.Queue[NodeDistance], connection
 			newCircuit.nodes[nodeBID] = true
 			circuits = append(circuits, newCircuit)
 			totalConnectionsMade++
-			fmt.Printf("added connection between %d and node %d\n", nodeAID, nodeBID)
 			continue
 		} else if circuitAIndex != -1 && circuitBIndex != -1 {
 			if circuitAIndex != circuitBIndex { // merge two circuits
@@ -133,7 +109,6 @@ func makingConnections(nodeDistanceQueue commons.Queue[NodeDistance], connection
 
 				circuits = append(circuits[:circuitBIndex], circuits[circuitBIndex+1:]...)
 				totalConnectionsMade++
-				fmt.Printf("added connection between %d and node %d\n", nodeAID, nodeBID)
 				continue
 			}
 		} else { // add node to existing circuit
@@ -142,7 +117,6 @@ func makingConnections(nodeDistanceQueue commons.Queue[NodeDistance], connection
 			circuit.nodes[nodeAID] = true
 			circuit.nodes[nodeBID] = true
 			totalConnectionsMade++
-			fmt.Printf("added connection between %d and node %d\n", nodeAID, nodeBID)
 			continue
 		}
 
@@ -156,109 +130,15 @@ func makingConnections(nodeDistanceQueue commons.Queue[NodeDistance], connection
 	return circuits
 }
 
-func allInOneCircuit(circuits Circuits, totalNodes int) bool {
-	if len(circuits) != 1 {
-		return false
-	}
-	for _, circuit := range circuits {
-		if len(circuit.nodes) != totalNodes {
-			return false
-		}
-	}
-	return true
-}
-
-// keep making connections until all nodes are connected in one circuit, return the last distances pair used
-func makingConnectionsUntilAllConnected(nodeDistanceQueue commons.Queue[NodeDistance], totalNodes int) NodeDistance {
-	circuits := Circuits{}
-	totalConnectionsMade := 0
-
-	var lastDistanceNodePair NodeDistance
-	var ok bool
-	for !allInOneCircuit(circuits, totalNodes) {
-		lastDistanceNodePair, ok = nodeDistanceQueue.Dequeue()
-		if !ok {
-			break
-		}
-		nodeAID := lastDistanceNodePair.nodePair[0]
-		nodeBID := lastDistanceNodePair.nodePair[1]
-
-		var circuitAIndex, circuitBIndex = -1, -1
-		for ci, circuit := range circuits {
-			if circuit.nodes[nodeAID] {
-				circuitAIndex = ci
-			}
-			if circuit.nodes[nodeBID] {
-				circuitBIndex = ci
-			}
-		}
-
-		if circuitAIndex == -1 && circuitBIndex == -1 {
-			// create a new circuit
-			newCircuit := Circuit{nodes: make(map[int]bool)}
-			newCircuit.nodes[nodeAID] = true
-			newCircuit.nodes[nodeBID] = true
-			circuits = append(circuits, newCircuit)
-			totalConnectionsMade++
-			fmt.Printf("added connection between %d and node %d\n", nodeAID, nodeBID)
-			continue
-		} else if circuitAIndex != -1 && circuitBIndex != -1 {
-			if circuitAIndex != circuitBIndex { // merge two circuits
-				circuitA := circuits[circuitAIndex]
-				circuitB := circuits[circuitBIndex]
-				for nodeID := range circuitB.nodes {
-					circuitA.nodes[nodeID] = true
-				}
-
-				circuits = append(circuits[:circuitBIndex], circuits[circuitBIndex+1:]...)
-				totalConnectionsMade++
-				fmt.Printf("added connection between %d and node %d\n", nodeAID, nodeBID)
-				continue
-			}
-		} else { // add node to existing circuit
-			circuitIdx := max(circuitAIndex, circuitBIndex)
-			circuit := circuits[circuitIdx]
-			circuit.nodes[nodeAID] = true
-			circuit.nodes[nodeBID] = true
-			totalConnectionsMade++
-			fmt.Printf("added connection between %d and node %d\n", nodeAID, nodeBID)
-			continue
-		}
-
-	}
-
-	return lastDistanceNodePair
-}
-
 func Part2() {
 	nodes := ParseInput()
 
-	nodeDistances := make([]NodeDistance, 0)
-
-	for i, nodeA := range nodes {
-		for j := i + 1; j < len(nodes); j++ {
-			nodeB := nodes[j]
-			dist := Distance(nodeA, nodeB)
-			nodeDistances = append(nodeDistances, NodeDistance{
-				distance: dist,
-				nodePair: [2]int{nodeA.id, nodeB.id},
-			})
-		}
+	// the connection that makes one circuit is the longest one of the minimum spanning tree
+	lastDistanceNodePair, ok := LastConnection(nodes)
+	if !ok {
+		fmt.Println("need at least two nodes to make a connection")
+		return
 	}
-
-	// sort the nodeDistances by distance
-	sort.Slice(nodeDistances, func(i, j int) bool {
-		return nodeDistances[i].distance < nodeDistances[j].distance
-	})
-
-	// put these node distances into a queue
-	nodeDistanceQueue := commons.Queue[NodeDistance]{}
-	for _, nd := range nodeDistances {
-		nodeDistanceQueue.Enqueue(nd)
-	}
-
-	// keep making connections until there is only one circuit, keep track of the last two pairs connected
-	lastDistanceNodePair := makingConnectionsUntilAllConnected(nodeDistanceQueue, len(nodes))
 	fmt.Println("last nodepair connected:", lastDistanceNodePair)
 	// print the product of the x value of the last two nodes connected
 	var nodeA, nodeB Node
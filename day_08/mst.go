@@ -0,0 +1,80 @@
+package main
+
+// less orders node distances by distance, breaking ties by the node ids, so that
+// every way of connecting the nodes agrees on which pair comes first.
+func (nd NodeDistance) less(other NodeDistance) bool {
+	if nd.distance != other.distance {
+		return nd.distance < other.distance
+	}
+	if nd.nodePair[0] != other.nodePair[0] {
+		return nd.nodePair[0] < other.nodePair[0]
+	}
+	return nd.nodePair[1] < other.nodePair[1]
+}
+
+func newNodeDistance(a, b Node) NodeDistance {
+	if a.id > b.id {
+		a, b = b, a
+	}
+	return NodeDistance{distance: Distance(a, b), nodePair: [2]int{a.id, b.id}}
+}
+
+// MinimumSpanningTree returns the connections that join all the nodes into one circuit
+// when pairs are connected closest first, in the order Prim's algorithm finds them.
+//
+// It never holds more than one candidate connection per node: every step adds the node
+// closest to the tree and then checks whether that node is now the closest tree node for
+// any of the others. That is O(n²) time but only O(n) memory, where sorting all the pairs
+// needs n²/2 of them in memory at once.
+func MinimumSpanningTree(nodes []Node) []NodeDistance {
+	if len(nodes) < 2 {
+		return nil
+	}
+
+	inTree := make([]bool, len(nodes))
+	best := make([]NodeDistance, len(nodes)) // the closest tree node for every other node
+	hasBest := make([]bool, len(nodes))
+	tree := make([]NodeDistance, 0, len(nodes)-1)
+
+	current := 0
+	inTree[current] = true
+	for len(tree) < len(nodes)-1 {
+		next := -1
+		for i, node := range nodes {
+			if inTree[i] {
+				continue
+			}
+			// the pair is only built when it can win, this loop runs n² times
+			if !hasBest[i] || Distance(nodes[current], node) <= best[i].distance {
+				candidate := newNodeDistance(nodes[current], node)
+				if !hasBest[i] || candidate.less(best[i]) {
+					best[i] = candidate
+					hasBest[i] = true
+				}
+			}
+			if next < 0 || best[i].less(best[next]) {
+				next = i
+			}
+		}
+		inTree[next] = true
+		tree = append(tree, best[next])
+		current = next
+	}
+	return tree
+}
+
+// LastConnection returns the connection that, connecting the closest pairs first, finally
+// joins all the nodes into one circuit: the longest connection of the minimum spanning tree.
+func LastConnection(nodes []Node) (NodeDistance, bool) {
+	tree := MinimumSpanningTree(nodes)
+	if len(tree) == 0 {
+		return NodeDistance{}, false
+	}
+	last := tree[0]
+	for _, nd := range tree[1:] {
+		if last.less(nd) {
+			last = nd
+		}
+	}
+	return last, true
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+const exampleNodes = `162,817,812
+57,618,57
+906,360,560
+592,479,940
+352,342,300
+466,668,158
+542,29,236
+431,825,988
+739,650,466
+52,470,668
+216,146,977
+819,987,18
+117,168,530
+805,96,715
+346,949,466
+970,615,88
+941,993,340
+862,61,35
+984,92,344
+425,690,689
+`
+
+// allPairs is the way the pairs used to be found: every one of them, sorted
+func allPairs(nodes []Node) []NodeDistance {
+	pairs := make([]NodeDistance, 0)
+	for i, nodeA := range nodes {
+		for j := i + 1; j < len(nodes); j++ {
+			pairs = append(pairs, newNodeDistance(nodeA, nodes[j]))
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i].less(pairs[j]) })
+	return pairs
+}
+
+// kruskalLast connects all the pairs closest first, with a union-find, and returns the
+// pair that joined the last two circuits
+func kruskalLast(nodes []Node) NodeDistance {
+	parent := make(map[int]int)
+	var find func(id int) int
+	find = func(id int) int {
+		if p, ok := parent[id]; ok && p != id {
+			parent[id] = find(p)
+			return parent[id]
+		}
+		return id
+	}
+
+	var last NodeDistance
+	circuits := len(nodes)
+	for _, nd := range allPairs(nodes) {
+		a, b := find(nd.nodePair[0]), find(nd.nodePair[1])
+		if a == b {
+			continue
+		}
+		parent[a] = b
+		last = nd
+		if circuits--; circuits == 1 {
+			break
+		}
+	}
+	return last
+}
+
+// randomNodes keeps the coordinates in a small range, so that there are many equal distances
+func randomNodes(rng *rand.Rand, n, spread int) []Node {
+	nodes := make([]Node, n)
+	for i := range nodes {
+		nodes[i] = Node{x: rng.Intn(spread) - spread/2, y: rng.Intn(spread), z: rng.Intn(spread) * 3, id: i}
+	}
+	return nodes
+}
+
+func TestExampleConnections(t *testing.T) {
+	nodes := ParseNodes(exampleNodes)
+
+	circuits := makingConnections(ClosestPairs(nodes, 10))
+	sizes := []int{len(circuits[0].nodes), len(circuits[1].nodes), len(circuits[2].nodes)}
+	if !reflect.DeepEqual(sizes, []int{5, 4, 2}) {
+		t.Errorf("largest circuits = %v; want [5 4 2]", sizes)
+	}
+
+	last, ok := LastConnection(nodes)
+	if !ok {
+		t.Fatal("LastConnection() found no connection")
+	}
+	if last.nodePair != [2]int{10, 12} || nodes[10].x*nodes[12].x != 25272 {
+		t.Errorf("LastConnection() = %+v; want nodes 10 and 12", last)
+	}
+}
+
+func TestClosestPairsMatchesAllPairs(t *testing.T) {
+	rng := rand.New(rand.NewSource(49))
+	for _, n := range []int{0, 1, 2, 3, 10, 60, 150} {
+		for _, spread := range []int{4, 50, 10000} {
+			nodes := randomNodes(rng, n, spread)
+			want := allPairs(nodes)
+			got := ClosestPairs(nodes, len(want)+5)
+			if len(got) != len(want) || (len(want) > 0 && !reflect.DeepEqual(got, want)) {
+				t.Fatalf("%d nodes, spread %d: ClosestPairs() differs from sorting all pairs", n, spread)
+			}
+		}
+	}
+}
+
+func TestLastConnectionMatchesKruskal(t *testing.T) {
+	rng := rand.New(rand.NewSource(8))
+	for i := 0; i < 30; i++ {
+		nodes := randomNodes(rng, 2+rng.Intn(60), []int{5, 100, 100000}[i%3])
+		want := kruskalLast(nodes)
+		got, ok := LastConnection(nodes)
+		if !ok || got != want {
+			t.Fatalf("LastConnection() = %+v; want %+v", got, want)
+		}
+		if tree := MinimumSpanningTree(nodes); len(tree) != len(nodes)-1 {
+			t.Fatalf("MinimumSpanningTree() has %d connections for %d nodes", len(tree), len(nodes))
+		}
+	}
+
+	if _, ok := LastConnection([]Node{{id: 0}}); ok {
+		t.Error("a single node has no connection")
+	}
+}